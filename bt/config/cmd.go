@@ -0,0 +1,352 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/DavidGamba/go-getoptions"
+	"github.com/mattn/go-isatty"
+)
+
+// Filename - Name of the config file searched from the current dir upwards.
+const Filename = ".bt.cue"
+
+// NewCommand - Config related commands, they don't require a valid config file.
+func NewCommand(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
+	opt := parent.NewCommand("config", "config file related tasks")
+
+	initCmd := opt.NewCommand("init", "Create a .bt.cue config file in the current dir based on the current tree")
+	initCmd.Bool("yes", false, initCmd.Description("Don't prompt, use the detected values"), initCmd.Alias("y"))
+	initCmd.Bool("force", false, initCmd.Description("Overwrite an existing config file"))
+	initCmd.SetCommandFn(initRun)
+
+	validate := opt.NewCommand("validate", "Validate the config file against the schema")
+	validate.HelpSynopsisArg("[<file>]", "Config file to validate, defaults to the .bt.cue found from the current dir upwards")
+	validate.SetCommandFn(validateRun)
+
+	pathCmd := opt.NewCommand("path", "Show the config file in use")
+	pathCmd.SetCommandFn(pathRun)
+
+	dump := opt.NewCommand("dump", "Show the final config values")
+	dump.Bool("json", false, dump.Description("Output in json format"))
+	dump.SetCommandFn(dumpRun)
+
+	return opt
+}
+
+func pathRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	f, err := FindFileUpwards(ctx, Filename)
+	if err != nil {
+		return fmt.Errorf("failed to find config file: %w", err)
+	}
+	fmt.Println(f)
+	return nil
+}
+
+func validateRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	f := ""
+	if len(args) > 0 {
+		f = args[0]
+	} else {
+		var err error
+		f, err = FindFileUpwards(ctx, Filename)
+		if err != nil {
+			return fmt.Errorf("failed to find config file: %w", err)
+		}
+	}
+
+	cfg, err := load(ctx, f)
+	if err != nil {
+		return err
+	}
+	warnings, err := Check(cfg)
+	for _, w := range warnings {
+		Logger.Printf("WARNING: %s\n", w)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid config '%s': %w", f, err)
+	}
+	Logger.Printf("valid config: %s\n", f)
+	return nil
+}
+
+func dumpRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	asJSON := opt.Value("json").(bool)
+
+	f, err := FindFileUpwards(ctx, Filename)
+	if err != nil {
+		return fmt.Errorf("failed to find config file: %w", err)
+	}
+	cfg, err := load(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("config file: %s\n", cfg.ConfigFile)
+	fmt.Printf("config root: %s\n", cfg.ConfigRoot)
+	fmt.Printf("default terraform profile: %s\n", cfg.Config.DefaultTerraformProfile)
+	fmt.Printf("terraform profile env var: %s\n", cfg.Config.TerraformProfileEnvVar)
+	fmt.Printf("terraform profiles:\n")
+	for _, name := range cfg.ProfileNames() {
+		fmt.Printf("  %s: %s\n", name, cfg.TFProfile[name])
+	}
+	return nil
+}
+
+func load(ctx context.Context, filename string) (*Config, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file '%s': %w", filename, err)
+	}
+	defer fh.Close()
+	cfg, err := Read(ctx, filename, fh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	err = SetDefaults(ctx, cfg, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set config defaults: %w", err)
+	}
+	return cfg, nil
+}
+
+// Check - Validates the config values that can't be expressed in the schema.
+// Returns warnings for settings that point to missing files.
+func Check(cfg *Config) ([]string, error) {
+	warnings := []string{}
+	if len(cfg.TFProfile) == 0 {
+		return warnings, fmt.Errorf("no terraform_profile defined")
+	}
+	if _, ok := cfg.TFProfile[cfg.Config.DefaultTerraformProfile]; !ok {
+		return warnings, fmt.Errorf("default terraform profile '%s' not defined, profiles: %v", cfg.Config.DefaultTerraformProfile, cfg.ProfileNames())
+	}
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.TFProfile[name]
+		if p.Workspaces.Enabled {
+			dir := p.Workspaces.Dir
+			if !path.IsAbs(dir) {
+				dir = path.Join(cfg.ConfigRoot, dir)
+			}
+			if _, err := os.Stat(dir); err != nil {
+				warnings = append(warnings, fmt.Sprintf("profile '%s': workspaces dir not found: %s", name, p.Workspaces.Dir))
+			}
+		}
+		if _, err := exec.LookPath(p.BinaryName); err != nil {
+			warnings = append(warnings, fmt.Sprintf("profile '%s': binary not found in PATH: %s", name, p.BinaryName))
+		}
+	}
+	return warnings, nil
+}
+
+// ProfileNames - Sorted list of terraform profile names.
+func (c *Config) ProfileNames() []string {
+	names := []string{}
+	for name := range c.TFProfile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scaffold - Values used to generate a new config file.
+type scaffold struct {
+	Profile       string
+	BinaryName    string
+	BackendConfig []string
+	WorkspacesDir string
+	Checks        []Command
+}
+
+// detectScaffold - Detects backend config files, the workspaces dir and pre-apply checks from the files in fsys.
+func detectScaffold(fsys fs.FS) scaffold {
+	s := scaffold{Profile: "default", BinaryName: "terraform"}
+
+	for _, glob := range []string{"*.tfbackend", "backend*.tfvars", "backend*.hcl"} {
+		matches, _ := fs.Glob(fsys, glob)
+		s.BackendConfig = append(s.BackendConfig, matches...)
+	}
+
+	hasVarFiles := func(dir string) bool {
+		matches, _ := fs.Glob(fsys, path.Join(dir, "*.tfvars*"))
+		return len(matches) > 0
+	}
+	for _, dir := range []string{"envs", "environments", "workspaces", "vars", "tfvars"} {
+		if hasVarFiles(dir) {
+			s.WorkspacesDir = dir
+			break
+		}
+	}
+	if s.WorkspacesDir == "" {
+		entries, _ := fs.ReadDir(fsys, ".")
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && hasVarFiles(e.Name()) {
+				s.WorkspacesDir = e.Name()
+				break
+			}
+		}
+	}
+
+	if fi, err := fs.Stat(fsys, "policy"); err == nil && fi.IsDir() {
+		s.Checks = append(s.Checks, Command{Name: "conftest", Command: []string{"conftest", "test", "$TERRAFORM_JSON_PLAN"}, Files: []string{"policy/*"}})
+	}
+	if _, err := fs.Stat(fsys, ".tflint.hcl"); err == nil {
+		s.Checks = append(s.Checks, Command{Name: "tflint", Command: []string{"tflint"}, Files: []string{".tflint.hcl"}})
+	}
+	return s
+}
+
+var identifierRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func quoteList(l []string) string {
+	q := []string{}
+	for _, e := range l {
+		q = append(q, fmt.Sprintf("%q", e))
+	}
+	return "[" + strings.Join(q, ", ") + "]"
+}
+
+// render - Renders the scaffold as a config file.
+func (s scaffold) render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "config: {\n")
+	fmt.Fprintf(&b, "\tdefault_terraform_profile: %q\n", s.Profile)
+	fmt.Fprintf(&b, "\tterraform_profile_env_var: \"BT_TERRAFORM_PROFILE\"\n")
+	fmt.Fprintf(&b, "}\n")
+	fmt.Fprintf(&b, "terraform_profile: {\n")
+	if identifierRe.MatchString(s.Profile) {
+		fmt.Fprintf(&b, "\t%s: {\n", s.Profile)
+	} else {
+		fmt.Fprintf(&b, "\t%q: {\n", s.Profile)
+	}
+	fmt.Fprintf(&b, "\t\tbinary_name: %q\n", s.BinaryName)
+	if len(s.BackendConfig) > 0 {
+		fmt.Fprintf(&b, "\t\tinit: {\n\t\t\tbackend_config: %s\n\t\t}\n", quoteList(s.BackendConfig))
+	}
+	if s.WorkspacesDir != "" {
+		fmt.Fprintf(&b, "\t\tworkspaces: {\n\t\t\tenabled: true\n\t\t\tdir: %q\n\t\t}\n", s.WorkspacesDir)
+	}
+	if len(s.Checks) > 0 {
+		fmt.Fprintf(&b, "\t\tpre_apply_checks: {\n\t\t\tenabled: true\n\t\t\tcommands: [\n")
+		for _, c := range s.Checks {
+			fmt.Fprintf(&b, "\t\t\t\t{name: %q, command: %s, files: %s},\n", c.Name, quoteList(c.Command), quoteList(c.Files))
+		}
+		fmt.Fprintf(&b, "\t\t\t]\n\t\t}\n")
+	}
+	fmt.Fprintf(&b, "\t}\n")
+	fmt.Fprintf(&b, "}\n")
+	return b.String()
+}
+
+func initRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	yes := opt.Value("yes").(bool)
+	force := opt.Value("force").(bool)
+
+	if _, err := os.Stat(Filename); err == nil && !force {
+		return fmt.Errorf("config file already exists: %s, use --force to overwrite", Filename)
+	}
+
+	s := detectScaffold(os.DirFS("."))
+	if _, err := exec.LookPath("terraform"); err != nil {
+		if _, err := exec.LookPath("tofu"); err == nil {
+			s.BinaryName = "tofu"
+		}
+	}
+
+	if !yes && isatty.IsTerminal(os.Stdin.Fd()) {
+		p := &prompter{r: bufio.NewReader(os.Stdin), w: os.Stderr}
+		s.Profile = p.String("Profile name", s.Profile)
+		s.BinaryName = p.String("Binary name", s.BinaryName)
+		s.BackendConfig = p.List("Backend config files", s.BackendConfig)
+		s.WorkspacesDir = p.String("Workspaces dir, use - to disable workspaces", s.WorkspacesDir)
+		if s.WorkspacesDir == "-" {
+			s.WorkspacesDir = ""
+		}
+		checks := []Command{}
+		for _, c := range s.Checks {
+			if p.Bool(fmt.Sprintf("Add '%s' pre-apply check", c.Name), true) {
+				checks = append(checks, c)
+			}
+		}
+		s.Checks = checks
+	}
+
+	data := s.render()
+	_, err := Read(ctx, Filename, strings.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("generated config is invalid: %w", err)
+	}
+	err = os.WriteFile(Filename, []byte(data), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	Logger.Printf("config written to: %s\n", Filename)
+	return nil
+}
+
+type prompter struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func (p *prompter) String(question, def string) string {
+	fmt.Fprintf(p.w, "%s [%s]: ", question, def)
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		return def
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def
+	}
+	return line
+}
+
+func (p *prompter) List(question string, def []string) []string {
+	answer := p.String(question+", comma separated", strings.Join(def, ","))
+	l := []string{}
+	for _, e := range strings.Split(answer, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			l = append(l, e)
+		}
+	}
+	return l
+}
+
+func (p *prompter) Bool(question string, def bool) bool {
+	d := "Y/n"
+	if !def {
+		d = "y/N"
+	}
+	fmt.Fprintf(p.w, "%s [%s]: ", question, d)
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		return def
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
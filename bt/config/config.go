@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/DavidGamba/dgtools/cueutils"
 )
@@ -21,34 +22,43 @@ type Config struct {
 	Config struct {
 		DefaultTerraformProfile string `json:"default_terraform_profile"`
 		TerraformProfileEnvVar  string `json:"terraform_profile_env_var"`
+		BinaryDir               string `json:"binary_dir"`
 	} `json:"config"`
 	TFProfile  map[string]TerraformProfile `json:"terraform_profile"`
 	ConfigRoot string                      `json:"config_root"`
+	ConfigFile string                      `json:"config_file"`
 }
 
 type TerraformProfile struct {
 	ID   string `json:"id"`
 	Init struct {
-		BackendConfig []string `json:"backend_config"`
-	}
+		BackendConfig       []string          `json:"backend_config"`
+		BackendConfigValues map[string]string `json:"backend_config_values"`
+	} `json:"init"`
 	Plan struct {
 		VarFile []string `json:"var_file"`
-	}
+	} `json:"plan"`
 	Workspaces struct {
-		Enabled bool
-		Dir     string
-	}
+		Enabled bool   `json:"enabled"`
+		Dir     string `json:"dir"`
+	} `json:"workspaces"`
+	Apply struct {
+		MaxPlanAge string `json:"max_plan_age"`
+		AllowDirty bool   `json:"allow_dirty"`
+	} `json:"apply"`
 	PreApplyChecks struct {
-		Enabled  bool
-		Commands []Command
+		Enabled  bool      `json:"enabled"`
+		Commands []Command `json:"commands"`
 	} `json:"pre_apply_checks"`
 	BinaryName string `json:"binary_name"`
+	// Set when the binary is resolved from the required_version
+	BinaryVersion string `json:"binary_version"`
 }
 
 type Command struct {
-	Name    string
-	Command []string
-	Files   []string
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	Files   []string `json:"files"`
 }
 
 func (t TerraformProfile) String() string {
@@ -59,6 +69,23 @@ func (t TerraformProfile) String() string {
 		t.Workspaces.Enabled,
 		t.Workspaces.Dir,
 	)
+	if t.BinaryVersion != "" {
+		output += fmt.Sprintf(", binary version: %s", t.BinaryVersion)
+	}
+	if len(t.Init.BackendConfigValues) > 0 {
+		keys := []string{}
+		for k := range t.Init.BackendConfigValues {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		output += fmt.Sprintf(", backend_config values: %v", keys)
+	}
+	if t.Apply.MaxPlanAge != "" {
+		output += fmt.Sprintf(", max plan age: %s", t.Apply.MaxPlanAge)
+	}
+	if t.Apply.AllowDirty {
+		output += ", allow dirty"
+	}
 	if t.PreApplyChecks.Enabled {
 		output += ", pre_apply_checks: "
 		names := []string{}
@@ -112,6 +139,7 @@ func Get(ctx context.Context, filename string) (*Config, string, error) {
 
 func SetDefaults(ctx context.Context, cfg *Config, filename string) error {
 	cfg.ConfigRoot = filepath.Dir(filename)
+	cfg.ConfigFile = filename
 	return nil
 }
 
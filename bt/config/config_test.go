@@ -2,8 +2,10 @@ package config
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestConfig(t *testing.T) {
@@ -71,3 +73,62 @@ terraform_profile: {
 		t.Logf("%#v", cfg.TFProfile["tofu"])
 	})
 }
+
+func TestScaffold(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.tf":              {Data: []byte("")},
+		"backend.tfvars":       {Data: []byte("")},
+		"envs/dev.tfvars":      {Data: []byte("")},
+		"envs/prod.tfvars":     {Data: []byte("")},
+		"policy/deny.rego":     {Data: []byte("")},
+		"modules/vpc/main.tf":  {Data: []byte("")},
+		"modules/vpc/x.tfvars": {Data: []byte("")},
+	}
+	s := detectScaffold(fsys)
+	if !slices.Equal(s.BackendConfig, []string{"backend.tfvars"}) {
+		t.Errorf("unexpected backend config: %v", s.BackendConfig)
+	}
+	if s.WorkspacesDir != "envs" {
+		t.Errorf("expected workspaces dir envs, got '%s'", s.WorkspacesDir)
+	}
+	if len(s.Checks) != 1 || s.Checks[0].Name != "conftest" {
+		t.Errorf("unexpected checks: %v", s.Checks)
+	}
+
+	expected := `config: {
+	default_terraform_profile: "default"
+	terraform_profile_env_var: "BT_TERRAFORM_PROFILE"
+}
+terraform_profile: {
+	default: {
+		binary_name: "terraform"
+		init: {
+			backend_config: ["backend.tfvars"]
+		}
+		workspaces: {
+			enabled: true
+			dir: "envs"
+		}
+		pre_apply_checks: {
+			enabled: true
+			commands: [
+				{name: "conftest", command: ["conftest", "test", "$TERRAFORM_JSON_PLAN"], files: ["policy/*"]},
+			]
+		}
+	}
+}
+`
+	got := s.render()
+	if got != expected {
+		t.Errorf("unexpected render:\n%s", got)
+	}
+
+	ctx := context.Background()
+	cfg, err := Read(ctx, "config.cue", strings.NewReader(got))
+	if err != nil {
+		t.Fatalf("failed to read rendered config: %s", err)
+	}
+	if cfg.TFProfile["default"].Workspaces.Dir != "envs" {
+		t.Errorf("expected workspaces dir envs, got '%s'", cfg.TFProfile["default"].Workspaces.Dir)
+	}
+}
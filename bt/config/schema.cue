@@ -6,12 +6,17 @@ terraform_profile: [ID=_]: #TerraformProfile & {id: ID}
 #Config: {
 	default_terraform_profile: string | *"default"
 	terraform_profile_env_var: string | *"BT_TERRAFORM_PROFILE"
+	// Dir with <binary_name>_<version> binaries to pick from based on required_version
+	binary_dir: string | *"~/.bt/bin"
 }
 
 #TerraformProfile: {
 	id: string
 	init?: {
+		// Files, supports ${workspace}, ${profile} and env var interpolation
 		backend_config: [...string]
+		// Inline key=value backend settings, values support the same interpolation
+		backend_config_values?: [string]: string
 	}
 	plan?: {
 		var_file: [...string]
@@ -20,6 +25,12 @@ terraform_profile: [ID=_]: #TerraformProfile & {id: ID}
 		enabled: bool
 		dir: string
 	}
+	apply?: {
+		// Maximum age of a plan before apply refuses it, for example 24h or 7d
+		max_plan_age: string | *""
+		// Allow applying plans when there are uncommitted changes in the plan sources
+		allow_dirty: bool | *false
+	}
 	pre_apply_checks?: {
 		enabled: bool
 		commands: [...#Command]
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -23,21 +24,11 @@ func program(args []string) int {
 	defer func() { cancel(); <-done }()
 
 	// Read config and store it in context
-	cfg, _, err := config.Get(ctx, ".bt.cue")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
-		return 1
-	}
+	// The config commands don't require a valid config so errors are reported when running other commands.
+	cfg, _, cfgErr := config.Get(ctx, config.Filename)
 	ctx = config.NewConfigContext(ctx, cfg)
 
-	opt := getoptions.New()
-	opt.Self("", "Terraform build system built as a no lock-in wrapper")
-	opt.Bool("quiet", false, opt.GetEnv("QUIET"))
-	opt.SetUnknownMode(getoptions.Pass)
-
-	terraform.NewCommand(ctx, opt)
-
-	opt.HelpCommand("help", opt.Alias("?"))
+	opt := newCommand(ctx, cfgErr)
 	remaining, err := opt.Parse(args[1:])
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
@@ -46,6 +37,9 @@ func program(args []string) int {
 	if opt.Called("quiet") {
 		Logger.SetOutput(io.Discard)
 	}
+	if opt.Value("output").(string) == "json" {
+		terraform.EnableJSONOutput(os.Stdout)
+	}
 
 	err = opt.Dispatch(ctx, remaining)
 	if err != nil {
@@ -57,3 +51,27 @@ func program(args []string) int {
 	}
 	return 0
 }
+
+// newCommand - Builds the command tree, the terraform commands require the config in the context.
+func newCommand(ctx context.Context, cfgErr error) *getoptions.GetOpt {
+	opt := getoptions.New()
+	opt.Self("", "Terraform build system built as a no lock-in wrapper")
+	opt.Bool("quiet", false, opt.GetEnv("QUIET"))
+	opt.String("output", "text", opt.ValidValues("text", "json"), opt.GetEnv("BT_OUTPUT"),
+		opt.Description("Output format, json emits task events as JSON lines to stdout"))
+	opt.SetUnknownMode(getoptions.Pass)
+
+	if cfgErr != nil {
+		tf := opt.NewCommand("terraform", "terraform related tasks")
+		tf.SetUnknownMode(getoptions.Pass)
+		tf.SetCommandFn(func(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+			return fmt.Errorf("%w, run 'bt config init' to create one or 'bt config validate' to check it", cfgErr)
+		})
+	} else {
+		terraform.NewCommand(ctx, opt)
+	}
+	config.NewCommand(ctx, opt)
+
+	opt.HelpCommand("help", opt.Alias("?"))
+	return opt
+}
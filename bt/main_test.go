@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+)
+
+func TestNewCommand(t *testing.T) {
+	cfg := &config.Config{TFProfile: map[string]config.TerraformProfile{"default": {BinaryName: "terraform"}}}
+	cfg.Config.DefaultTerraformProfile = "default"
+	ctx := config.NewConfigContext(context.Background(), cfg)
+
+	// Child commands inherit the global options, defining them again panics
+	opt := newCommand(ctx, nil)
+	_, err := opt.Parse([]string{"--output", "json", "terraform", "suggest-moves", "--out-file", "moved.tf"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if opt.Value("output").(string) != "json" {
+		t.Errorf("expected json output, got %s", opt.Value("output"))
+	}
+}
@@ -18,13 +18,14 @@ func applyCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("apply", "")
-	opt.SetCommandFn(applyRun)
+	opt.SetCommandFn(taskFn("apply", resolveBinaryFn(applyRun)))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
 		Logger.Printf("WARNING: failed to list workspaces: %s\n", err)
 	}
 	opt.String("ws", "", opt.ValidValues(wss...), opt.Description("Workspace to use"))
+	opt.Bool("force-stale", false, opt.Description("Apply the plan even if it is stale"))
 
 	return opt
 }
@@ -32,6 +33,7 @@ func applyCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt
 func applyRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 	ws := opt.Value("ws").(string)
 	profile := opt.Value("profile").(string)
+	forceStale := opt.Value("force-stale").(bool)
 
 	cfg := config.ConfigFromContext(ctx)
 	Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
@@ -64,9 +66,20 @@ func applyRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 	}
 	if !modified {
 		Logger.Printf("no changes: skipping apply\n")
+		Events.Skipped("apply", ws, "plan already applied")
 		return nil
 	}
 	Logger.Printf("modified: %v\n", files)
+	Events.Emit(Event{Type: "sources_modified", Task: "apply", Workspace: ws, Modified: files})
+
+	if forceStale {
+		Logger.Printf("WARNING: --force-stale given, skipping plan freshness checks\n")
+	} else {
+		err = checkPlanProvenance(ctx, cfg, profile, planFile)
+		if err != nil {
+			return err
+		}
+	}
 
 	cmd := []string{cfg.TFProfile[profile].BinaryName, "apply"}
 	cmd = append(cmd, "-input", planFile)
@@ -82,9 +95,10 @@ func applyRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 		Logger.Printf("export %s\n", wsEnv)
 		ri.Env(wsEnv)
 	}
-	err = ri.Run()
+	err = runCMD("apply", ws, ri, cmd)
 	if err != nil {
 		os.Remove(planFile)
+		os.Remove(provenanceFile(planFile))
 		return fmt.Errorf("failed to run: %w", err)
 	}
 
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 
 	"github.com/DavidGamba/dgtools/bt/config"
@@ -18,7 +17,7 @@ func buildCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("build", "Wraps init, plan and apply into a single operation with a cache")
-	opt.SetCommandFn(buildRun)
+	opt.SetCommandFn(taskFn("build", resolveBinaryFn(buildRun)))
 	opt.StringSlice("var-file", 1, 1)
 	opt.Bool("destroy", false)
 	opt.Bool("detailed-exitcode", false)
@@ -26,7 +25,10 @@ func buildCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt
 	opt.Bool("no-checks", false, opt.Description("Do not run pre-apply checks"), opt.Alias("nc"))
 	opt.StringSlice("target", 1, 99)
 	opt.StringSlice("replace", 1, 99)
+	opt.Bool("pick", false, opt.Description("Interactively pick the resources to target from the state and the last plan"))
+	opt.Bool("pick-last", false, opt.Description("Target the resources picked in the previous --pick run"))
 	opt.Bool("apply", false, opt.Description("Apply Terraform plan"))
+	opt.Bool("force-stale", false, opt.Description("Apply the plan even if it is stale"))
 	opt.Bool("show", false, opt.Description("Show Terraform plan"))
 	opt.Bool("visualize", false, opt.Description("Visualize Terraform plan"))
 
@@ -64,27 +66,33 @@ func buildRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 	}
 
 	initFn := func(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
-		// TODO: Add logic to only run when files have been modified
-		if _, err := os.Stat(".tf.init"); os.IsNotExist(err) {
+		needed, reason, err := initNeeded(cfg, profile, ws)
+		if err != nil {
+			return err
+		}
+		if needed {
+			Logger.Printf("running init: %s\n", reason)
 			return initRun(ctx, opt, args)
 		}
+		Logger.Printf("no changes: skipping init\n")
+		Events.Skipped("init", ws, "no changes")
 		return nil
 	}
 
 	tm := dag.NewTaskMap()
-	tm.Add("init", initFn)
-	tm.Add("plan", planRun)
+	tm.Add("init", taskFn("init", initFn))
+	tm.Add("plan", taskFn("plan", planRun))
 	if cfg.TFProfile[profile].PreApplyChecks.Enabled {
-		tm.Add("checks", checksRun)
+		tm.Add("checks", taskFn("checks", checksRun))
 	}
 	if apply {
-		tm.Add("apply", applyRun)
+		tm.Add("apply", taskFn("apply", applyRun))
 	}
 	if show {
-		tm.Add("show", showPlanRun)
+		tm.Add("show", taskFn("show", showPlanRun))
 	}
 	if visualize {
-		tm.Add("visualize", visualizePlanRun)
+		tm.Add("visualize", taskFn("visualize", visualizePlanRun))
 	}
 
 	g := dag.NewGraph("build")
@@ -0,0 +1,338 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/dgtools/fsmodtime"
+	"github.com/DavidGamba/go-getoptions"
+)
+
+// cacheArtifact - A file or dir created by bt or terraform to cache results.
+type cacheArtifact struct {
+	// init, plan, plan-json, plan-meta, check, apply, pick or data-dir
+	Kind string
+	// Empty for the default workspace
+	Workspace string
+	// Only set for data dirs
+	Profile string
+	Path    string
+	ModTime time.Time
+}
+
+func cacheCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
+	profile := parent.Value("profile").(string)
+
+	cfg := config.ConfigFromContext(ctx)
+
+	opt := parent.NewCommand("cache", "Inspect and clean bt cache artifacts")
+
+	wss, err := validWorkspaces(cfg, profile)
+	if err != nil {
+		Logger.Printf("WARNING: failed to list workspaces: %s\n", err)
+	}
+
+	status := opt.NewCommand("status", "Show cache artifacts, their age and whether they are stale")
+	status.SetCommandFn(cacheStatusRun)
+
+	clean := opt.NewCommand("clean", "Remove cache artifacts, state files are never touched")
+	clean.String("ws", "", clean.ValidValues(wss...), clean.Description("Only remove artifacts for the given workspace"))
+	clean.String("older-than", "", clean.Description("Only remove artifacts older than the given age, for example 72h or 7d"))
+	clean.Bool("data-dirs", false, clean.Description("Also remove downloaded providers and modules from the TF_DATA_DIR dirs"))
+	clean.Bool("dry-run", false, clean.Description("Show what would be removed"))
+	clean.SetCommandFn(cacheCleanRun)
+
+	return opt
+}
+
+func cacheStatusRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	profile := opt.Value("profile").(string)
+
+	cfg := config.ConfigFromContext(ctx)
+	Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
+
+	artifacts, err := listCacheArtifacts(cfg.Config.DefaultTerraformProfile)
+	if err != nil {
+		return err
+	}
+	if len(artifacts) == 0 {
+		Logger.Printf("no cache artifacts found\n")
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current dir: %w", err)
+	}
+
+	now := time.Now()
+	tw := tabwriter.NewWriter(outputWriter(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "WORKSPACE\tPROFILE\tARTIFACT\tPATH\tAGE\tSTATUS\n")
+	for _, a := range artifacts {
+		ws := a.Workspace
+		if ws == "" {
+			ws = "-"
+		}
+		p := a.Profile
+		if p == "" {
+			p = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", ws, p, a.Kind, a.Path, humanAge(now.Sub(a.ModTime)), cacheArtifactStatus(cfg, profile, cwd, a))
+	}
+	return tw.Flush()
+}
+
+func cacheCleanRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	profile := opt.Value("profile").(string)
+	ws := opt.Value("ws").(string)
+	olderThan := opt.Value("older-than").(string)
+	dataDirs := opt.Value("data-dirs").(bool)
+	dryRun := opt.Value("dry-run").(bool)
+
+	cfg := config.ConfigFromContext(ctx)
+	Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
+
+	var cutoff time.Time
+	if olderThan != "" {
+		d, err := parseAge(olderThan)
+		if err != nil {
+			return err
+		}
+		cutoff = time.Now().Add(-d)
+	}
+
+	artifacts, err := listCacheArtifacts(cfg.Config.DefaultTerraformProfile)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range artifacts {
+		if opt.Called("ws") && a.Workspace != ws {
+			continue
+		}
+		if !cutoff.IsZero() && a.ModTime.After(cutoff) {
+			continue
+		}
+		paths := []string{a.Path}
+		if a.Kind == "data-dir" {
+			if !dataDirs {
+				continue
+			}
+			// Only remove what init downloads, keep the backend config and the selected workspace.
+			paths = []string{filepath.Join(a.Path, "providers"), filepath.Join(a.Path, "modules")}
+		}
+		for _, p := range paths {
+			if _, err := os.Stat(p); os.IsNotExist(err) {
+				continue
+			}
+			if dryRun {
+				Logger.Printf("would remove: %s\n", p)
+				continue
+			}
+			Logger.Printf("removing: %s\n", p)
+			err := os.RemoveAll(p)
+			if err != nil {
+				return fmt.Errorf("failed to remove '%s': %w", p, err)
+			}
+		}
+		if a.Kind == "data-dir" && !opt.Called("ws") && !dryRun {
+			// Downloaded providers are gone, force init to run again.
+			os.Remove(".tf.init")
+		}
+	}
+	return nil
+}
+
+// listCacheArtifacts - Lists the bt artifacts and TF_DATA_DIR dirs in the current dir.
+func listCacheArtifacts(defaultProfile string) ([]cacheArtifact, error) {
+	artifacts := []cacheArtifact{}
+	files, err := filepath.Glob(".tf.*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob cache files: %w", err)
+	}
+	for _, f := range files {
+		kind, ws := parseCacheArtifactName(f)
+		if kind == "" {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, cacheArtifact{Kind: kind, Workspace: ws, Path: f, ModTime: fi.ModTime()})
+	}
+
+	dirs, err := filepath.Glob(".terraform*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob data dirs: %w", err)
+	}
+	for _, d := range dirs {
+		fi, err := os.Stat(d)
+		if err != nil || !fi.IsDir() {
+			continue
+		}
+		p := defaultProfile
+		if d != ".terraform" {
+			p = strings.TrimPrefix(d, ".terraform-")
+		}
+		artifacts = append(artifacts, cacheArtifact{Kind: "data-dir", Profile: p, Path: d, ModTime: fi.ModTime()})
+	}
+
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		if artifacts[i].Workspace != artifacts[j].Workspace {
+			return artifacts[i].Workspace < artifacts[j].Workspace
+		}
+		return artifacts[i].Path < artifacts[j].Path
+	})
+	return artifacts, nil
+}
+
+// parseCacheArtifactName - Returns the artifact kind and workspace from a file name like .tf.plan-dev.json.
+// Returns an empty kind for unknown files.
+func parseCacheArtifactName(name string) (string, string) {
+	name = strings.TrimPrefix(name, ".tf.")
+	suffix := ""
+	for _, ext := range []string{".json", ".meta"} {
+		if strings.HasSuffix(name, ext) {
+			name = strings.TrimSuffix(name, ext)
+			suffix = "-" + strings.TrimPrefix(ext, ".")
+		}
+	}
+	kind, ws, _ := strings.Cut(name, "-")
+	switch kind {
+	case "init", "plan", "check", "apply", "pick":
+		return kind + suffix, ws
+	}
+	return "", ""
+}
+
+// cacheArtifactStatus - Reuses the same modification checks used by the commands that create the artifacts.
+func cacheArtifactStatus(cfg *config.Config, profile, cwd string, a cacheArtifact) string {
+	target := filepath.Join("./", cwd, a.Path)
+	planFile := ".tf.plan"
+	if a.Workspace != "" {
+		planFile = fmt.Sprintf(".tf.plan-%s", a.Workspace)
+	}
+	plan := filepath.Join("./", cwd, planFile)
+
+	sources := []string{}
+	switch a.Kind {
+	case "plan":
+		defaultVarFiles, err := getDefaultVarFiles(cfg, profile)
+		if err != nil {
+			return "unknown"
+		}
+		varFiles, err := AddVarFileIfWorkspaceSelected(cfg, profile, a.Workspace, []string{})
+		if err != nil {
+			return "unknown"
+		}
+		sources, err = planSources(cwd, defaultVarFiles, varFiles)
+		if err != nil {
+			return "unknown"
+		}
+	case "plan-json", "plan-meta":
+		sources = []string{plan}
+	case "check":
+		globs, err := checkSources(cfg, profile, cwd)
+		if err != nil {
+			return "unknown"
+		}
+		sources = append(globs, plan)
+	case "apply":
+		_, modified, err := fsmodtime.Target(os.DirFS("/"), []string{target}, []string{plan})
+		if err != nil {
+			return "unknown"
+		}
+		if modified {
+			return "pending apply"
+		}
+		return "applied"
+	case "init":
+		ws, err := updateWSIfSelected(cfg.Config.DefaultTerraformProfile, profile, "")
+		if err != nil {
+			return "unknown"
+		}
+		needed, reason, err := initNeeded(cfg, profile, ws)
+		if err != nil {
+			return "unknown"
+		}
+		if needed {
+			return fmt.Sprintf("stale (%s)", reason)
+		}
+		return "fresh"
+	case "data-dir":
+		return fmt.Sprintf("%.1f MB", float64(dirSize(a.Path))/1024/1024)
+	default:
+		return "-"
+	}
+
+	files, modified, err := fsmodtime.Target(os.DirFS("/"), []string{target}, sources)
+	if err != nil {
+		return "unknown"
+	}
+	if modified {
+		if len(files) > 0 {
+			rel, err := filepath.Rel(cwd, "/"+files[0])
+			if err != nil {
+				rel = files[0]
+			}
+			return fmt.Sprintf("stale (%s)", rel)
+		}
+		return "stale"
+	}
+	return "fresh"
+}
+
+// parseAge - Like time.ParseDuration but also accepts days, for example 7d.
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid age '%s': %w", s, err)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age '%s': %w", s, err)
+	}
+	return d, nil
+}
+
+func humanAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+// dirSize - Total size of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				size += fi.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,59 @@
+package terraform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCacheArtifactName(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		ws   string
+	}{
+		{".tf.init", "init", ""},
+		{".tf.plan", "plan", ""},
+		{".tf.plan-dev", "plan", "dev"},
+		{".tf.plan-us-east-1", "plan", "us-east-1"},
+		{".tf.plan.json", "plan-json", ""},
+		{".tf.plan-dev.json", "plan-json", "dev"},
+		{".tf.plan-dev.meta", "plan-meta", "dev"},
+		{".tf.check-dev", "check", "dev"},
+		{".tf.apply", "apply", ""},
+		{".tf.pick-dev", "pick", "dev"},
+		{".tf.unknown", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kind, ws := parseCacheArtifactName(test.name)
+			if kind != test.kind || ws != test.ws {
+				t.Errorf("expected (%q, %q), got (%q, %q)", test.kind, test.ws, kind, ws)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		err      bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			d, err := parseAge(test.input)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, d)
+			}
+		})
+	}
+}
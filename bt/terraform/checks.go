@@ -22,7 +22,7 @@ func checksCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOp
 	opt.StringSlice("var-file", 1, 1)
 	opt.Bool("no-checks", false, opt.Description("Do not run pre-apply checks"), opt.Alias("nc"))
 	opt.Bool("ignore-cache", false, opt.Description("ignore the cache and re-run the checks"), opt.Alias("ic"))
-	opt.SetCommandFn(checksRun)
+	opt.SetCommandFn(taskFn("checks", resolveBinaryFn(checksRun)))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -41,6 +41,7 @@ func checksRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 	nc := opt.Value("no-checks").(bool)
 	if nc {
 		Logger.Printf("WARNING: no-checks flag passed. Skipping pre-apply checks.\n")
+		Events.Skipped("checks", ws, "no-checks flag passed")
 		return nil
 	}
 
@@ -75,23 +76,9 @@ func checksRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 	os.Setenv("TERRAFORM_JSON_PLAN", jsonPlan)
 	os.Setenv("CONFIG_ROOT", cfg.ConfigRoot)
 
-	cmdFiles := []string{}
-	for _, cmd := range cfg.TFProfile[profile].PreApplyChecks.Commands {
-		exp, err := fsmodtime.ExpandEnv(cmd.Files)
-		if err != nil {
-			return fmt.Errorf("failed to expand: %w", err)
-		}
-		for _, f := range exp {
-			if strings.HasPrefix(f, "/") {
-				cmdFiles = append(cmdFiles, filepath.Join("./", f))
-			} else {
-				cmdFiles = append(cmdFiles, filepath.Join("./", cwd, f))
-			}
-		}
-	}
-	globs, _, err := fsmodtime.Glob(os.DirFS("/"), false, cmdFiles)
+	globs, err := checkSources(cfg, profile, cwd)
 	if err != nil {
-		return fmt.Errorf("failed to glob sources: %w", err)
+		return err
 	}
 
 	// Paths tested with fs.FS can't start with "/". See https://pkg.go.dev/io/fs#ValidPath
@@ -105,17 +92,11 @@ func checksRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 
 	if !ignoreCache && !modified {
 		Logger.Printf("no changes: skipping check\n")
+		Events.Skipped("checks", ws, "no changes")
 		return nil
 	}
 	if len(files) > 0 {
-		modifiedFiles := []string{}
-		for _, f := range files {
-			rel, err := filepath.Rel(cwd, "/"+f)
-			if err != nil {
-				rel = f
-			}
-			modifiedFiles = append(modifiedFiles, rel)
-		}
+		modifiedFiles := Events.ModifiedSources("checks", ws, cwd, files)
 		Logger.Printf("modified: %v\n", modifiedFiles)
 	} else {
 		Logger.Printf("missing target: %v\n", checkFile)
@@ -143,7 +124,7 @@ func checksRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 			return fmt.Errorf("failed to expand: %w", err)
 		}
 		ri := run.CMD(exp...).Ctx(ctx).Stdin().Log().Env(dataDir)
-		err = ri.Run()
+		err = runCMD("checks", ws, ri, exp)
 		if err != nil {
 			return fmt.Errorf("failed to run: %w", err)
 		}
@@ -157,3 +138,27 @@ func checksRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error
 
 	return nil
 }
+
+// checkSources - Returns the files used by the pre-apply check commands.
+// Paths are relative to "/" so they can be used with os.DirFS("/").
+func checkSources(cfg *config.Config, profile, cwd string) ([]string, error) {
+	cmdFiles := []string{}
+	for _, cmd := range cfg.TFProfile[profile].PreApplyChecks.Commands {
+		exp, err := fsmodtime.ExpandEnv(cmd.Files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand: %w", err)
+		}
+		for _, f := range exp {
+			if strings.HasPrefix(f, "/") {
+				cmdFiles = append(cmdFiles, filepath.Join("./", f))
+			} else {
+				cmdFiles = append(cmdFiles, filepath.Join("./", cwd, f))
+			}
+		}
+	}
+	globs, _, err := fsmodtime.Glob(os.DirFS("/"), false, cmdFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob sources: %w", err)
+	}
+	return globs, nil
+}
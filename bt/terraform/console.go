@@ -14,7 +14,7 @@ func consoleCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetO
 
 	opt := parent.NewCommand("console", "")
 	opt.StringSlice("var-file", 1, 1)
-	opt.SetCommandFn(consoleRun)
+	opt.SetCommandFn(resolveBinaryFn(consoleRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -0,0 +1,211 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DavidGamba/go-getoptions"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+// moduleNode - A module call in the module tree.
+type moduleNode struct {
+	Name    string
+	Source  string
+	Version string
+	// Absolute path to the module dir, empty for remote modules.
+	Dir      string
+	Remote   bool
+	Cycle    bool
+	Children []*moduleNode
+}
+
+func depsCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
+	opt := parent.NewCommand("deps", "Show the module dependency tree")
+	opt.String("format", "text", opt.ValidValues("text", "dot"), opt.Description("Output format, dot outputs a Graphviz graph"))
+	opt.SetCommandFn(depsRun)
+	return opt
+}
+
+func depsRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	format := opt.Value("format").(string)
+
+	root, err := loadModuleTree(".")
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "dot":
+		printModuleTreeDot(outputWriter(), root)
+	default:
+		printModuleTree(outputWriter(), root)
+	}
+	return nil
+}
+
+// loadModuleTree - Walks local module calls recursively starting at dir.
+// Remote modules are included as leaves.
+// A module that calls one of its ancestors is marked as a cycle and not expanded.
+func loadModuleTree(dir string) (*moduleNode, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abs path: %w", err)
+	}
+	root := &moduleNode{Name: ".", Source: dir, Dir: abs}
+	cache := map[string]*tfconfig.Module{}
+	err = walkModuleTree(root, cache, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func walkModuleTree(node *moduleNode, cache map[string]*tfconfig.Module, stack map[string]bool) error {
+	if stack[node.Dir] {
+		node.Cycle = true
+		return nil
+	}
+	stack[node.Dir] = true
+	defer delete(stack, node.Dir)
+
+	module, ok := cache[node.Dir]
+	if !ok {
+		var diags tfconfig.Diagnostics
+		module, diags = tfconfig.LoadModule(node.Dir)
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to load module '%s': %w", node.Dir, diags)
+		}
+		cache[node.Dir] = module
+	}
+
+	names := []string{}
+	for name := range module.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		call := module.ModuleCalls[name]
+		child := &moduleNode{Name: name, Source: call.Source, Version: call.Version}
+		node.Children = append(node.Children, child)
+
+		dir := filepath.Join(node.Dir, call.Source)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			Logger.Printf("remote module: %s, %s\n", name, call.Source)
+			child.Remote = true
+			continue
+		}
+		child.Dir = dir
+		err := walkModuleTree(child, cache, stack)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localModuleDirs - Returns the deduplicated list of local module dirs called directly or indirectly by the root module.
+func localModuleDirs(root *moduleNode) []string {
+	dirs := []string{}
+	seen := map[string]bool{root.Dir: true}
+	var walk func(n *moduleNode)
+	walk = func(n *moduleNode) {
+		for _, c := range n.Children {
+			if c.Remote {
+				continue
+			}
+			if !seen[c.Dir] {
+				seen[c.Dir] = true
+				dirs = append(dirs, c.Dir)
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	return dirs
+}
+
+func (n *moduleNode) String() string {
+	switch {
+	case n.Remote && n.Version != "":
+		return fmt.Sprintf("%s: %s (remote, version: %s)", n.Name, n.Source, n.Version)
+	case n.Remote:
+		return fmt.Sprintf("%s: %s (remote)", n.Name, n.Source)
+	case n.Cycle:
+		return fmt.Sprintf("%s: %s (cycle)", n.Name, n.Source)
+	}
+	return fmt.Sprintf("%s: %s", n.Name, n.Source)
+}
+
+func printModuleTree(w io.Writer, root *moduleNode) {
+	fmt.Fprintln(w, root.Source)
+	var walk func(n *moduleNode, prefix string)
+	walk = func(n *moduleNode, prefix string) {
+		for i, c := range n.Children {
+			branch, indent := "├── ", "│   "
+			if i == len(n.Children)-1 {
+				branch, indent = "└── ", "    "
+			}
+			fmt.Fprintf(w, "%s%s%s\n", prefix, branch, c)
+			walk(c, prefix+indent)
+		}
+	}
+	walk(root, "")
+}
+
+func printModuleTreeDot(w io.Writer, root *moduleNode) {
+	cwd := root.Dir
+	id := func(n *moduleNode) string {
+		if n.Remote {
+			return n.Source
+		}
+		rel, err := filepath.Rel(cwd, n.Dir)
+		if err != nil {
+			return n.Dir
+		}
+		return rel
+	}
+
+	nodes := []string{}
+	edges := []string{}
+	seen := map[string]bool{}
+	var walk func(n *moduleNode)
+	walk = func(n *moduleNode) {
+		nid := id(n)
+		if !seen["node:"+nid] {
+			seen["node:"+nid] = true
+			label := nid
+			attrs := ""
+			if n.Remote {
+				if n.Version != "" {
+					label = fmt.Sprintf("%s\\n%s", nid, n.Version)
+				}
+				attrs = ", shape=box, style=dashed"
+			}
+			nodes = append(nodes, fmt.Sprintf("\t%q [label=\"%s\"%s];", nid, label, attrs))
+		}
+		for _, c := range n.Children {
+			edge := fmt.Sprintf("\t%q -> %q [label=%q];", nid, id(c), c.Name)
+			if !seen["edge:"+edge] {
+				seen["edge:"+edge] = true
+				edges = append(edges, edge)
+			}
+			if !c.Cycle {
+				walk(c)
+			}
+		}
+	}
+	walk(root)
+
+	fmt.Fprintln(w, "digraph modules {")
+	fmt.Fprintln(w, "\trankdir=LR;")
+	fmt.Fprintln(w, strings.Join(nodes, "\n"))
+	if len(edges) > 0 {
+		fmt.Fprintln(w, strings.Join(edges, "\n"))
+	}
+	fmt.Fprintln(w, "}")
+}
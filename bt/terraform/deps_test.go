@@ -0,0 +1,84 @@
+package terraform
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleTree(t *testing.T) {
+	Logger.SetOutput(io.Discard)
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.tf": `
+module "a" {
+  source = "./modules/a"
+}
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+}
+`,
+		"modules/a/main.tf": `
+module "b" {
+  source = "../b"
+}
+`,
+		"modules/b/main.tf": `
+module "a" {
+  source = "../a"
+}
+module "c" {
+  source = "../c"
+}
+`,
+		"modules/c/main.tf": ``,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		err = os.WriteFile(p, []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	root, err := loadModuleTree(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dirs := localModuleDirs(root)
+	expected := []string{
+		filepath.Join(dir, "modules/a"),
+		filepath.Join(dir, "modules/b"),
+		filepath.Join(dir, "modules/c"),
+	}
+	if len(dirs) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, dirs)
+	}
+	for i := range expected {
+		if dirs[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], dirs[i])
+		}
+	}
+
+	buf := bytes.Buffer{}
+	root.Source = "."
+	printModuleTree(&buf, root)
+	expectedTree := `.
+├── a: ./modules/a
+│   └── b: ../b
+│       ├── a: ../a (cycle)
+│       └── c: ../c
+└── vpc: terraform-aws-modules/vpc/aws (remote, version: 5.1.0)
+`
+	if buf.String() != expectedTree {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedTree, buf.String())
+	}
+}
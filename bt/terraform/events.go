@@ -0,0 +1,183 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/dgtools/run"
+	"github.com/DavidGamba/go-getoptions"
+)
+
+// Event - A structured event emitted as a JSON line when the json output mode is enabled.
+type Event struct {
+	Time time.Time `json:"time"`
+	// task_started, task_skipped, task_finished, command_started, command_finished, sources_modified or plan_summary
+	Type      string `json:"type"`
+	Task      string `json:"task"`
+	Profile   string `json:"profile,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	// success, failure or skipped
+	Status     string       `json:"status,omitempty"`
+	Reason     string       `json:"reason,omitempty"`
+	Argv       []string     `json:"argv,omitempty"`
+	ExitCode   *int         `json:"exit_code,omitempty"`
+	DurationMS *int64       `json:"duration_ms,omitempty"`
+	Modified   []string     `json:"modified,omitempty"`
+	Changes    *planChanges `json:"changes,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+type eventWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	skipped map[string]string
+}
+
+// Events - Writes the events, it is disabled unless EnableJSONOutput is called.
+var Events = &eventWriter{skipped: map[string]string{}}
+
+// EnableJSONOutput - Emit events as JSON lines to w.
+// Command output is sent to stderr so w only has events when w is os.Stdout.
+func EnableJSONOutput(w io.Writer) {
+	Events.mu.Lock()
+	defer Events.mu.Unlock()
+	Events.w = w
+}
+
+func (e *eventWriter) Enabled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.w != nil
+}
+
+func (e *eventWriter) Emit(ev Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.w == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		Logger.Printf("failed to marshal event: %s\n", err)
+		return
+	}
+	e.w.Write(append(data, '\n'))
+}
+
+// Skipped - Records that the task was skipped and why.
+func (e *eventWriter) Skipped(task, ws, reason string) {
+	e.mu.Lock()
+	e.skipped[task] = reason
+	e.mu.Unlock()
+	e.Emit(Event{Type: "task_skipped", Task: task, Workspace: ws, Reason: reason})
+}
+
+// ModifiedSources - Records the sources that triggered the task to run.
+// Paths are relative to "/" as returned by fsmodtime.Target.
+func (e *eventWriter) ModifiedSources(task, ws, cwd string, files []string) []string {
+	modified := []string{}
+	for _, f := range files {
+		rel, err := filepath.Rel(cwd, "/"+f)
+		if err != nil {
+			rel = f
+		}
+		modified = append(modified, rel)
+	}
+	e.Emit(Event{Type: "sources_modified", Task: task, Workspace: ws, Modified: modified})
+	return modified
+}
+
+// taskFn - Wraps the given task emitting started and finished events.
+func taskFn(task string, fn getoptions.CommandFn) getoptions.CommandFn {
+	return func(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+		if !Events.Enabled() {
+			return fn(ctx, opt, args)
+		}
+		profile := opt.Value("profile").(string)
+		ws := ""
+		if v, ok := opt.Value("ws").(string); ok {
+			ws = v
+		}
+		cfg := config.ConfigFromContext(ctx)
+		if selected, err := updateWSIfSelected(cfg.Config.DefaultTerraformProfile, profile, ws); err == nil {
+			ws = selected
+		}
+
+		Events.mu.Lock()
+		delete(Events.skipped, task)
+		Events.mu.Unlock()
+
+		Events.Emit(Event{Type: "task_started", Task: task, Profile: profile, Workspace: ws})
+		start := time.Now()
+		err := fn(ctx, opt, args)
+		duration := time.Since(start).Milliseconds()
+
+		ev := Event{Type: "task_finished", Task: task, Profile: profile, Workspace: ws, Status: "success", DurationMS: &duration}
+		Events.mu.Lock()
+		reason, skipped := Events.skipped[task]
+		Events.mu.Unlock()
+		if skipped {
+			ev.Status = "skipped"
+			ev.Reason = reason
+		}
+		if err != nil {
+			ev.Status = "failure"
+			ev.Error = err.Error()
+			ev.ExitCode = exitCode(err)
+		}
+		Events.Emit(ev)
+		return err
+	}
+}
+
+// runCMD - Runs the command emitting command events.
+// In json output mode the command output is sent to stderr so stdout only has events.
+func runCMD(task, ws string, ri *run.RunInfo, cmd []string) error {
+	if !Events.Enabled() {
+		return ri.Run()
+	}
+	Events.Emit(Event{Type: "command_started", Task: task, Workspace: ws, Argv: cmd})
+	start := time.Now()
+	err := ri.Run(os.Stderr, os.Stderr)
+	duration := time.Since(start).Milliseconds()
+	ev := Event{Type: "command_finished", Task: task, Workspace: ws, Argv: cmd, DurationMS: &duration, ExitCode: exitCode(err)}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	Events.Emit(ev)
+	return err
+}
+
+// outputWriter - Returns the writer for plain text command output.
+// In json output mode it is stderr so stdout only has events.
+func outputWriter() io.Writer {
+	if Events.Enabled() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// exitCode - Returns the exit code for the given error, nil when it is unknown.
+func exitCode(err error) *int {
+	code := 0
+	if err == nil {
+		return &code
+	}
+	var eerr *exec.ExitError
+	if errors.As(err, &eerr) {
+		code = eerr.ExitCode()
+		return &code
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DavidGamba/go-getoptions"
+)
+
+func TestTaskFnEvents(t *testing.T) {
+	buf := &bytes.Buffer{}
+	EnableJSONOutput(buf)
+	defer EnableJSONOutput(nil)
+
+	opt := getoptions.New()
+	opt.String("profile", "default")
+	opt.String("ws", "dev")
+
+	skip := taskFn("plan", func(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+		Events.Skipped("plan", "dev", "no changes")
+		return nil
+	})
+	fail := taskFn("apply", func(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+		return errors.New("boom")
+	})
+	if err := skip(context.Background(), opt, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := fail(context.Background(), opt, nil); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	events := []Event{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		ev := Event{}
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("invalid json line %q: %s", line, err)
+		}
+		events = append(events, ev)
+	}
+	expected := []struct {
+		Type   string
+		Task   string
+		Status string
+		Reason string
+	}{
+		{"task_started", "plan", "", ""},
+		{"task_skipped", "plan", "", "no changes"},
+		{"task_finished", "plan", "skipped", "no changes"},
+		{"task_started", "apply", "", ""},
+		{"task_finished", "apply", "failure", ""},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d:\n%s", len(expected), len(events), buf.String())
+	}
+	for i, e := range expected {
+		ev := events[i]
+		if ev.Type != e.Type || ev.Task != e.Task || ev.Status != e.Status || ev.Reason != e.Reason {
+			t.Errorf("event %d: expected %+v, got %+v", i, e, ev)
+		}
+		if ev.Workspace != "dev" {
+			t.Errorf("event %d: expected workspace dev, got %q", i, ev.Workspace)
+		}
+	}
+	if events[4].Error != "boom" {
+		t.Errorf("expected error boom, got %q", events[4].Error)
+	}
+}
+
+func TestPlanChanges(t *testing.T) {
+	data := `{"format_version": "1.2", "resource_changes": [
+		{"address": "a.a", "mode": "managed", "change": {"actions": ["create"]}},
+		{"address": "a.b", "mode": "managed", "change": {"actions": ["update"]}},
+		{"address": "a.c", "mode": "managed", "change": {"actions": ["delete"]}},
+		{"address": "a.d", "mode": "managed", "change": {"actions": ["delete", "create"]}},
+		{"address": "a.e", "mode": "managed", "change": {"actions": ["no-op"]}},
+		{"address": "data.a.f", "mode": "data", "change": {"actions": ["read"]}}
+	]}`
+	plan, err := readPlanJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := plan.changes()
+	expected := planChanges{Add: 2, Change: 1, Destroy: 2}
+	if got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
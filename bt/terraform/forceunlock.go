@@ -16,7 +16,7 @@ func forceUnlockCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("force-unlock", "")
-	opt.SetCommandFn(forceUnlockRun)
+	opt.SetCommandFn(resolveBinaryFn(forceUnlockRun))
 	opt.HelpSynopsisArg("<lock-id>", "Lock ID")
 
 	wss, err := validWorkspaces(cfg, profile)
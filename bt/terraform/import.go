@@ -2,9 +2,19 @@ package terraform
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/dgtools/run"
 	"github.com/DavidGamba/go-getoptions"
+	"github.com/mattn/go-isatty"
+	"gopkg.in/yaml.v3"
 )
 
 func importCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
@@ -14,7 +24,9 @@ func importCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOp
 
 	opt := parent.NewCommand("import", "")
 	opt.StringSlice("var-file", 1, 1)
-	opt.SetCommandFn(importRun)
+	opt.String("from", "", opt.ArgName("file"), opt.Description("CSV or YAML file with the address and id of the resources to import"))
+	opt.Bool("import-blocks", false, opt.Description("Print Terraform import blocks for the resources in --from instead of importing them"))
+	opt.SetCommandFn(resolveBinaryFn(importRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -27,10 +39,238 @@ func importCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOp
 
 func importRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 	profile := opt.Value("profile").(string)
+	from := opt.Value("from").(string)
+	importBlocks := opt.Value("import-blocks").(bool)
 	i := invalidatePlan{}
 
 	cfg := config.ConfigFromContext(ctx)
 	Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
 
+	if from != "" {
+		return importFromRun(ctx, opt, from, importBlocks, args)
+	}
+	if importBlocks {
+		return fmt.Errorf("--import-blocks requires --from")
+	}
+
 	return varFileCMDRun(i, cfg.TFProfile[profile].BinaryName, "import")(ctx, opt, args)
 }
+
+// importEntry - A resource to import.
+type importEntry struct {
+	Address string `yaml:"address"`
+	ID      string `yaml:"id"`
+}
+
+// importResult - The outcome of importing a resource.
+type importResult struct {
+	importEntry
+	// imported, skipped or failed
+	Status string
+	Err    error
+}
+
+// importFromRun - Imports all the resources in the mapping file continuing on error.
+// Resources already in the state are skipped.
+func importFromRun(ctx context.Context, opt *getoptions.GetOpt, from string, importBlocks bool, args []string) error {
+	profile := opt.Value("profile").(string)
+	varFiles := opt.Value("var-file").([]string)
+	ws := opt.Value("ws").(string)
+
+	cfg := config.ConfigFromContext(ctx)
+
+	entries, err := readImportFile(from)
+	if err != nil {
+		return err
+	}
+
+	ws, varFileArgs, err := resolveVarFiles(cfg, profile, ws, varFiles)
+	if err != nil {
+		return err
+	}
+
+	inState := map[string]bool{}
+	addresses, err := stateAddresses(ctx, cfg, profile, ws)
+	if err != nil {
+		Logger.Printf("WARNING: %s\n", err)
+	}
+	for _, a := range addresses {
+		inState[a] = true
+	}
+
+	if importBlocks {
+		pending := []importEntry{}
+		for _, e := range entries {
+			if inState[e.Address] {
+				Logger.Printf("already in state: skipping %s\n", e.Address)
+				continue
+			}
+			pending = append(pending, e)
+		}
+		return writeImportBlocks(outputWriter(), pending)
+	}
+
+	dataDir := fmt.Sprintf("TF_DATA_DIR=%s", getDataDir(cfg.Config.DefaultTerraformProfile, profile))
+	Logger.Printf("export %s\n", dataDir)
+
+	results := []importResult{}
+	imported := false
+	for _, e := range entries {
+		if inState[e.Address] {
+			Logger.Printf("already in state: skipping %s\n", e.Address)
+			results = append(results, importResult{importEntry: e, Status: "skipped"})
+			continue
+		}
+		cmd := []string{cfg.TFProfile[profile].BinaryName, "import", "-input=false"}
+		cmd = append(cmd, varFileArgs...)
+		if !isatty.IsTerminal(os.Stdout.Fd()) {
+			cmd = append(cmd, "-no-color")
+		}
+		cmd = append(cmd, args...)
+		cmd = append(cmd, e.Address, e.ID)
+		ri := run.CMD(cmd...).Ctx(ctx).Log().Env(dataDir)
+		if ws != "" {
+			ri.Env(fmt.Sprintf("TF_WORKSPACE=%s", ws))
+		}
+		err := runCMD("import", ws, ri, cmd)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return fmt.Errorf("failed to import '%s': %w", e.Address, err)
+			}
+			Logger.Printf("ERROR: failed to import '%s': %s\n", e.Address, err)
+			results = append(results, importResult{importEntry: e, Status: "failed", Err: err})
+			continue
+		}
+		imported = true
+		results = append(results, importResult{importEntry: e, Status: "imported"})
+	}
+	if imported {
+		invalidatePlan{}.successFunction(ws)
+	}
+
+	failed := printImportReport(os.Stderr, results)
+	if failed > 0 {
+		return fmt.Errorf("failed to import %d of %d resources", failed, len(entries))
+	}
+	return nil
+}
+
+// printImportReport - Prints the import summary and returns the number of failures.
+func printImportReport(w io.Writer, results []importResult) int {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	fmt.Fprintf(w, "\nimported: %d, skipped: %d, failed: %d\n", counts["imported"], counts["skipped"], counts["failed"])
+	for _, r := range results {
+		if r.Status == "failed" {
+			fmt.Fprintf(w, "  FAILED: %s %s: %s\n", r.Address, r.ID, r.Err)
+		}
+	}
+	return counts["failed"]
+}
+
+// readImportFile - Reads the address and id pairs from a CSV or YAML file based on its extension.
+func readImportFile(file string) ([]importEntry, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer fh.Close()
+
+	var entries []importEntry
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv":
+		entries, err = readImportCSV(fh)
+	case ".yaml", ".yml":
+		entries, err = readImportYAML(fh)
+	default:
+		return nil, fmt.Errorf("unsupported import file '%s': use a .csv, .yaml or .yml file", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", file, err)
+	}
+
+	seen := map[string]bool{}
+	for i, e := range entries {
+		if e.Address == "" || e.ID == "" {
+			return nil, fmt.Errorf("failed to read '%s': entry %d is missing the address or the id", file, i+1)
+		}
+		if seen[e.Address] {
+			return nil, fmt.Errorf("failed to read '%s': duplicate address '%s'", file, e.Address)
+		}
+		seen[e.Address] = true
+	}
+	return entries, nil
+}
+
+// readImportCSV - Reads address,id records.
+// An optional header row naming the address and id columns allows for other columns or a different order.
+func readImportCSV(r io.Reader) ([]importEntry, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	entries := []importEntry{}
+	if len(records) == 0 {
+		return entries, nil
+	}
+
+	addressCol, idCol := 0, 1
+	header := map[string]int{}
+	for i, h := range records[0] {
+		header[strings.TrimSpace(h)] = i
+	}
+	if col, ok := header["address"]; ok {
+		addressCol = col
+		idCol, ok = header["id"]
+		if !ok {
+			return nil, fmt.Errorf("missing id column in header")
+		}
+		records = records[1:]
+	}
+	for i, record := range records {
+		if len(record) <= addressCol || len(record) <= idCol {
+			return nil, fmt.Errorf("record %d: expected address and id columns", i+1)
+		}
+		entries = append(entries, importEntry{
+			Address: strings.TrimSpace(record[addressCol]),
+			ID:      strings.TrimSpace(record[idCol]),
+		})
+	}
+	return entries, nil
+}
+
+// readImportYAML - Reads a list of maps with address and id keys.
+func readImportYAML(r io.Reader) ([]importEntry, error) {
+	entries := []importEntry{}
+	err := yaml.NewDecoder(r).Decode(&entries)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// writeImportBlocks - Writes Terraform 1.5+ import blocks for the given entries.
+func writeImportBlocks(w io.Writer, entries []importEntry) error {
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		_, err := fmt.Fprintf(w, "import {\n  to = %s\n  id = %s\n}\n", e.Address, hclQuote(e.ID))
+		if err != nil {
+			return fmt.Errorf("failed to write import block: %w", err)
+		}
+	}
+	return nil
+}
+
+// hclQuote - Quotes s as an HCL string escaping template sequences.
+func hclQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + r.Replace(s) + `"`
+}
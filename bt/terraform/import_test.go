@@ -0,0 +1,101 @@
+package terraform
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadImportFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected []importEntry
+		err      bool
+	}{
+		{"csv", "imports.csv", "aws_s3_bucket.a,bucket-a\n# comment\naws_s3_bucket.b, bucket-b\n", []importEntry{
+			{Address: "aws_s3_bucket.a", ID: "bucket-a"},
+			{Address: "aws_s3_bucket.b", ID: "bucket-b"},
+		}, false},
+		{"csv header", "imports.csv", "id,note,address\nbucket-a,x,aws_s3_bucket.a\n", []importEntry{
+			{Address: "aws_s3_bucket.a", ID: "bucket-a"},
+		}, false},
+		{"csv quoted address", "imports.csv", "address,id\n\"module.m.aws_s3_bucket.this[\"\"a\"\"]\",bucket-a\n", []importEntry{
+			{Address: `module.m.aws_s3_bucket.this["a"]`, ID: "bucket-a"},
+		}, false},
+		{"csv header without id", "imports.csv", "address,name\naws_s3_bucket.a,bucket-a\n", nil, true},
+		{"csv missing id", "imports.csv", "aws_s3_bucket.a\n", nil, true},
+		{"yaml", "imports.yaml", "- address: aws_s3_bucket.a\n  id: bucket-a\n- address: aws_s3_bucket.b\n  id: bucket-b\n", []importEntry{
+			{Address: "aws_s3_bucket.a", ID: "bucket-a"},
+			{Address: "aws_s3_bucket.b", ID: "bucket-b"},
+		}, false},
+		{"yaml empty id", "imports.yml", "- address: aws_s3_bucket.a\n", nil, true},
+		{"duplicate", "imports.csv", "aws_s3_bucket.a,bucket-a\naws_s3_bucket.a,bucket-b\n", nil, true},
+		{"unsupported", "imports.txt", "aws_s3_bucket.a bucket-a\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), tt.file)
+			err := os.WriteFile(file, []byte(tt.content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err := readImportFile(file)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error, got: %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(entries, tt.expected) {
+				t.Errorf("expected: %v, got: %v", tt.expected, entries)
+			}
+		})
+	}
+}
+
+func TestWriteImportBlocks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := writeImportBlocks(buf, []importEntry{
+		{Address: "aws_s3_bucket.a", ID: "bucket-a"},
+		{Address: `aws_iam_role_policy.this["x"]`, ID: `role:"${x}"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `import {
+  to = aws_s3_bucket.a
+  id = "bucket-a"
+}
+
+import {
+  to = aws_iam_role_policy.this["x"]
+  id = "role:\"$${x}\""
+}
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestPrintImportReport(t *testing.T) {
+	buf := &bytes.Buffer{}
+	failed := printImportReport(buf, []importResult{
+		{importEntry: importEntry{Address: "a.a", ID: "1"}, Status: "imported"},
+		{importEntry: importEntry{Address: "a.b", ID: "2"}, Status: "skipped"},
+		{importEntry: importEntry{Address: "a.c", ID: "3"}, Status: "failed", Err: os.ErrNotExist},
+	})
+	if failed != 1 {
+		t.Errorf("expected 1 failure, got: %d", failed)
+	}
+	expected := "\nimported: 1, skipped: 1, failed: 1\n  FAILED: a.c 3: file does not exist\n"
+	if buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+}
@@ -2,8 +2,13 @@ package terraform
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/DavidGamba/dgtools/bt/config"
@@ -14,28 +19,45 @@ import (
 )
 
 func initCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
+	profile := parent.Value("profile").(string)
+
+	cfg := config.ConfigFromContext(ctx)
+
 	opt := parent.NewCommand("init", "")
-	opt.SetCommandFn(initRun)
+	opt.SetCommandFn(taskFn("init", resolveBinaryFn(initRun)))
+
+	wss, err := validWorkspaces(cfg, profile)
+	if err != nil {
+		Logger.Printf("WARNING: failed to list workspaces: %s\n", err)
+	}
+	opt.String("ws", "", opt.ValidValues(wss...), opt.Description("Workspace to use for the backend config interpolation"))
+
 	return opt
 }
 
 func initRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 	profile := opt.Value("profile").(string)
+	ws := opt.Value("ws").(string)
 
 	cfg := config.ConfigFromContext(ctx)
 	Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
 
-	cmd := []string{cfg.TFProfile[profile].BinaryName, "init"}
+	ws, err := updateWSIfSelected(cfg.Config.DefaultTerraformProfile, profile, ws)
+	if err != nil {
+		return err
+	}
 
-	for _, bvars := range cfg.TFProfile[profile].Init.BackendConfig {
-		b := strings.ReplaceAll(bvars, "~", "$HOME")
-		bb, err := fsmodtime.ExpandEnv([]string{b})
-		if err != nil {
-			return fmt.Errorf("failed to expand: %w", err)
-		}
-		if _, err := os.Stat(bb[0]); err == nil {
-			cmd = append(cmd, "-backend-config", bb[0])
-		}
+	backendArgs, _, err := backendConfigArgs(cfg, profile, ws)
+	if err != nil {
+		return err
+	}
+
+	cmd := []string{cfg.TFProfile[profile].BinaryName, "init"}
+	cmd = append(cmd, backendArgs...)
+	if prev, err := os.ReadFile(".tf.init"); err == nil && len(prev) > 0 && string(prev) != initFileContent(backendArgs) {
+		// The backend config changed, for example when it is interpolated with a different workspace.
+		Logger.Printf("backend config changed: reconfiguring backend\n")
+		cmd = append(cmd, "-reconfigure")
 	}
 	if !isatty.IsTerminal(os.Stdout.Fd()) {
 		cmd = append(cmd, "-no-color")
@@ -43,15 +65,154 @@ func initRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 	cmd = append(cmd, args...)
 	dataDir := fmt.Sprintf("TF_DATA_DIR=%s", getDataDir(cfg.Config.DefaultTerraformProfile, profile))
 	Logger.Printf("export %s\n", dataDir)
-	err := run.CMD(cmd...).Ctx(ctx).Stdin().Log().Env(dataDir).Run()
+	ri := run.CMD(cmd...).Ctx(ctx).Stdin().Log().Env(dataDir)
+	err = runCMD("init", ws, ri, cmd)
 	if err != nil {
 		return fmt.Errorf("failed to run: %w", err)
 	}
-	fh, err := os.Create(".tf.init")
+
+	// Save the backend config used so init can re-run when it changes.
+	err = os.WriteFile(".tf.init", []byte(initFileContent(backendArgs)), 0644)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
-	fh.Close()
 
 	return nil
 }
+
+// initFileContent - Returns a hash of the backend args.
+// The args are not saved as they are since interpolated values can include credentials.
+func initFileContent(backendArgs []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(backendArgs, "\n")))
+	return hex.EncodeToString(sum[:]) + "\n"
+}
+
+// interpolate - Replaces ${workspace} and ${profile} and then expands env vars and a leading ~.
+// The default workspace is named default.
+func interpolate(s, profile, ws string) (string, error) {
+	if ws == "" {
+		ws = "default"
+	}
+	s = strings.ReplaceAll(s, "${workspace}", ws)
+	s = strings.ReplaceAll(s, "${profile}", profile)
+	if s == "~" || strings.HasPrefix(s, "~/") {
+		s = "$HOME" + s[1:]
+	}
+	ss, err := fsmodtime.ExpandEnv([]string{s})
+	if err != nil {
+		return "", fmt.Errorf("failed to expand '%s': %w", s, err)
+	}
+	return ss[0], nil
+}
+
+// backendConfigArgs - Returns the -backend-config args for the given workspace and the backend config files used.
+// Backend config files that don't exist are skipped.
+func backendConfigArgs(cfg *config.Config, profile, ws string) ([]string, []string, error) {
+	args := []string{}
+	files := []string{}
+	for _, bvars := range cfg.TFProfile[profile].Init.BackendConfig {
+		b, err := interpolate(bvars, profile, ws)
+		if err != nil {
+			return args, files, err
+		}
+		if _, err := os.Stat(b); err == nil {
+			args = append(args, "-backend-config", b)
+			files = append(files, b)
+		} else {
+			Logger.Printf("WARNING: backend config file not found: %s\n", b)
+		}
+	}
+
+	keys := []string{}
+	for k := range cfg.TFProfile[profile].Init.BackendConfigValues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := interpolate(cfg.TFProfile[profile].Init.BackendConfigValues[k], profile, ws)
+		if err != nil {
+			return args, files, err
+		}
+		args = append(args, "-backend-config", fmt.Sprintf("%s=%s", k, v))
+	}
+	return args, files, nil
+}
+
+var requiredProvidersRe = regexp.MustCompile(`required_providers\s*{`)
+
+// initSources - Returns the files that require init to re-run when modified:
+// The backend config files, the lock file and the files that declare required_providers in the root and local modules.
+// Paths are relative to "/" so they can be used with os.DirFS("/").
+func initSources(cwd string, backendFiles []string) ([]string, error) {
+	files := append([]string{}, backendFiles...)
+	if _, err := os.Stat(".terraform.lock.hcl"); err == nil {
+		files = append(files, ".terraform.lock.hcl")
+	}
+
+	moduleTree, err := loadModuleTree(".")
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range append([]string{moduleTree.Dir}, localModuleDirs(moduleTree)...) {
+		tfFiles, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob: %w", err)
+		}
+		for _, f := range tfFiles {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read '%s': %w", f, err)
+			}
+			if requiredProvidersRe.Match(data) {
+				files = append(files, f)
+			}
+		}
+	}
+
+	sources := []string{}
+	for _, f := range files {
+		if strings.HasPrefix(f, "/") {
+			sources = append(sources, filepath.Join("./", f))
+		} else {
+			sources = append(sources, filepath.Join("./", cwd, f))
+		}
+	}
+	return sources, nil
+}
+
+// initNeeded - Returns whether init needs to run and why.
+func initNeeded(cfg *config.Config, profile, ws string) (bool, string, error) {
+	prev, err := os.ReadFile(".tf.init")
+	if err != nil {
+		return true, "missing .tf.init", nil
+	}
+	backendArgs, backendFiles, err := backendConfigArgs(cfg, profile, ws)
+	if err != nil {
+		return false, "", err
+	}
+	// .tf.init files created by older versions of bt are empty
+	if len(prev) > 0 && string(prev) != initFileContent(backendArgs) {
+		return true, "backend config changed", nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get current dir: %w", err)
+	}
+	sources, err := initSources(cwd, backendFiles)
+	if err != nil {
+		return false, "", err
+	}
+	if len(sources) == 0 {
+		return false, "", nil
+	}
+	files, modified, err := fsmodtime.Target(os.DirFS("/"), []string{filepath.Join("./", cwd, ".tf.init")}, sources)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check changes for: '.tf.init': %w", err)
+	}
+	if modified {
+		modifiedFiles := Events.ModifiedSources("init", ws, cwd, files)
+		return true, fmt.Sprintf("modified: %v", modifiedFiles), nil
+	}
+	return false, "", nil
+}
@@ -0,0 +1,82 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("BT_TEST_REGION", "us-west-2")
+	t.Setenv("HOME", "/home/bt")
+	tests := []struct {
+		input    string
+		ws       string
+		expected string
+		err      bool
+	}{
+		{"backend-${workspace}.tfvars", "dev", "backend-dev.tfvars", false},
+		{"backend-${workspace}.tfvars", "", "backend-default.tfvars", false},
+		{"state/${profile}/${workspace}", "dev", "state/tofu/dev", false},
+		{"bucket-${BT_TEST_REGION}", "dev", "bucket-us-west-2", false},
+		{"bucket-${BT_TEST_MISSING}", "dev", "", true},
+		{"~/backend.tfvars", "dev", "/home/bt/backend.tfvars", false},
+		{"key=a~b", "dev", "key=a~b", false},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := interpolate(test.input, "tofu", test.ws)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestBackendConfigArgs(t *testing.T) {
+	dir := t.TempDir()
+	devFile := filepath.Join(dir, "backend-dev.tfvars")
+	err := os.WriteFile(devFile, []byte(""), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p := config.TerraformProfile{}
+	p.Init.BackendConfig = []string{filepath.Join(dir, "backend-${workspace}.tfvars")}
+	p.Init.BackendConfigValues = map[string]string{
+		"key":    "${workspace}/terraform.tfstate",
+		"bucket": "state-${profile}",
+	}
+	cfg := &config.Config{TFProfile: map[string]config.TerraformProfile{"default": p}}
+
+	args, files, err := backendConfigArgs(cfg, "default", "dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{
+		"-backend-config", devFile,
+		"-backend-config", "bucket=state-default",
+		"-backend-config", "key=dev/terraform.tfstate",
+	}
+	if !slices.Equal(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+	if !slices.Equal(files, []string{devFile}) {
+		t.Errorf("unexpected files: %v", files)
+	}
+
+	// Missing files are skipped
+	args, files, err = backendConfigArgs(cfg, "default", "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(args) != 4 || len(files) != 0 {
+		t.Errorf("unexpected args: %v, files: %v", args, files)
+	}
+}
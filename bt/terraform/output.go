@@ -13,7 +13,7 @@ func outputCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOp
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("output", "")
-	opt.SetCommandFn(outputRun)
+	opt.SetCommandFn(resolveBinaryFn(outputRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
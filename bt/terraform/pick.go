@@ -0,0 +1,334 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/nsf/termbox-go"
+)
+
+var ErrPickCanceled = errors.New("selection canceled")
+
+// pickTargets - Returns the addresses to use as plan targets.
+// With last, the previous selection for the workspace is reused without prompting.
+func pickTargets(ctx context.Context, cfg *config.Config, profile, ws, planFile string, last bool) ([]string, error) {
+	pickFile := ".tf.pick"
+	if ws != "" {
+		pickFile = fmt.Sprintf(".tf.pick-%s", ws)
+	}
+	previous := []string{}
+	if data, err := os.ReadFile(pickFile); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				previous = append(previous, line)
+			}
+		}
+	}
+
+	if last {
+		if len(previous) == 0 {
+			return nil, fmt.Errorf("no previous selection found in: %s", pickFile)
+		}
+		Logger.Printf("using previous selection: %v\n", previous)
+		return previous, nil
+	}
+
+	candidates, err := pickCandidates(ctx, cfg, profile, ws, planFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no resources found in state or in the last plan")
+	}
+
+	p := newPicker(candidates, previous)
+	err = p.Run()
+	if err != nil {
+		return nil, err
+	}
+	selection := p.Selection()
+	if len(selection) == 0 {
+		return nil, fmt.Errorf("no resources selected")
+	}
+
+	err = os.WriteFile(pickFile, []byte(strings.Join(selection, "\n")+"\n"), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save selection: %w", err)
+	}
+	Logger.Printf("selected: %v\n", selection)
+	return selection, nil
+}
+
+// pickCandidates - Lists the addresses in the state and in the last plan.
+func pickCandidates(ctx context.Context, cfg *config.Config, profile, ws, planFile string) ([]string, error) {
+	seen := map[string]bool{}
+	addresses := []string{}
+	add := func(a string) {
+		if a != "" && !seen[a] {
+			seen[a] = true
+			addresses = append(addresses, a)
+		}
+	}
+
+	inState, err := stateAddresses(ctx, cfg, profile, ws)
+	if err != nil {
+		Logger.Printf("WARNING: %s\n", err)
+	}
+	for _, a := range inState {
+		add(a)
+	}
+
+	var data []byte
+	if d, err := os.ReadFile(planFile + ".json"); err == nil {
+		data = d
+	} else if _, err := os.Stat(planFile); err == nil {
+		data, err = showPlanJSON(ctx, cfg, profile, ws, planFile)
+		if err != nil {
+			Logger.Printf("WARNING: %s\n", err)
+		}
+	}
+	if len(data) > 0 {
+		plan, err := readPlanJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, rc := range plan.ResourceChanges {
+			add(rc.Address)
+		}
+	}
+
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// fuzzyMatch - Returns whether all the runes in pattern appear in order in s, and a score where higher is better.
+// Matching is case insensitive, consecutive matches and matches at the start of a word score higher.
+func fuzzyMatch(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	pr := []rune(strings.ToLower(pattern))
+	sr := []rune(strings.ToLower(s))
+	best, found := 0, false
+	// Try every occurrence of the first rune as the start of the match
+	for start, r := range sr {
+		if r != pr[0] {
+			continue
+		}
+		score, ok := fuzzyScore(pr, sr, start)
+		if !ok {
+			// Later starts can't match either
+			break
+		}
+		if !found || score > best {
+			best, found = score, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	// Prefer shorter strings
+	return best*100 - len(sr), true
+}
+
+// fuzzyScore - Greedily matches pr in sr starting at start.
+func fuzzyScore(pr, sr []rune, start int) (int, bool) {
+	score := 0
+	pi := 0
+	prev := -2
+	for si := start; si < len(sr) && pi < len(pr); si++ {
+		if sr[si] != pr[pi] {
+			continue
+		}
+		score++
+		if si == prev+1 {
+			score += 3
+		}
+		if si == 0 || !unicode.IsLetter(sr[si-1]) && !unicode.IsDigit(sr[si-1]) {
+			score += 2
+		}
+		prev = si
+		pi++
+	}
+	return score, pi == len(pr)
+}
+
+// picker - Multi-select list with a fuzzy filter.
+type picker struct {
+	items    []string
+	selected map[string]bool
+	query    string
+	matches  []string
+	cursor   int
+	offset   int
+}
+
+func newPicker(items, selected []string) *picker {
+	p := &picker{items: items, selected: map[string]bool{}}
+	for _, s := range selected {
+		p.selected[s] = true
+	}
+	p.filter()
+	return p
+}
+
+// filter - Updates the matches for the current query sorted by score.
+func (p *picker) filter() {
+	type match struct {
+		item  string
+		score int
+	}
+	mm := []match{}
+	for _, item := range p.items {
+		if score, ok := fuzzyMatch(p.query, item); ok {
+			mm = append(mm, match{item, score})
+		}
+	}
+	sort.SliceStable(mm, func(i, j int) bool { return mm[i].score > mm[j].score })
+	p.matches = []string{}
+	for _, m := range mm {
+		p.matches = append(p.matches, m.item)
+	}
+	p.cursor = 0
+	p.offset = 0
+}
+
+func (p *picker) move(n int) {
+	p.cursor += n
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+func (p *picker) toggle() {
+	if len(p.matches) == 0 {
+		return
+	}
+	item := p.matches[p.cursor]
+	p.selected[item] = !p.selected[item]
+}
+
+// toggleAll - Selects all the matches, or deselects them if they are all selected.
+func (p *picker) toggleAll() {
+	all := true
+	for _, m := range p.matches {
+		if !p.selected[m] {
+			all = false
+			break
+		}
+	}
+	for _, m := range p.matches {
+		p.selected[m] = !all
+	}
+}
+
+// Selection - Selected items in the original order.
+func (p *picker) Selection() []string {
+	s := []string{}
+	for _, item := range p.items {
+		if p.selected[item] {
+			s = append(s, item)
+		}
+	}
+	return s
+}
+
+func (p *picker) Run() error {
+	err := termbox.Init()
+	if err != nil {
+		return fmt.Errorf("failed to initialize terminal: %w", err)
+	}
+	defer termbox.Close()
+
+	for {
+		p.draw()
+		ev := termbox.PollEvent()
+		if ev.Type == termbox.EventError {
+			return ev.Err
+		}
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		_, h := termbox.Size()
+		page := h - 3
+		switch ev.Key {
+		case termbox.KeyEsc, termbox.KeyCtrlC:
+			return ErrPickCanceled
+		case termbox.KeyEnter:
+			return nil
+		case termbox.KeyArrowUp, termbox.KeyCtrlP:
+			p.move(-1)
+		case termbox.KeyArrowDown, termbox.KeyCtrlN:
+			p.move(1)
+		case termbox.KeyPgup:
+			p.move(-page)
+		case termbox.KeyPgdn:
+			p.move(page)
+		case termbox.KeySpace, termbox.KeyTab:
+			p.toggle()
+			p.move(1)
+		case termbox.KeyCtrlA:
+			p.toggleAll()
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if r := []rune(p.query); len(r) > 0 {
+				p.query = string(r[:len(r)-1])
+				p.filter()
+			}
+		default:
+			if ev.Ch != 0 {
+				p.query += string(ev.Ch)
+				p.filter()
+			}
+		}
+	}
+}
+
+func (p *picker) draw() {
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	w, h := termbox.Size()
+	printLine := func(y int, s string, fg, bg termbox.Attribute) {
+		x := 0
+		for _, r := range s {
+			if x >= w {
+				break
+			}
+			termbox.SetCell(x, y, r, fg, bg)
+			x++
+		}
+	}
+
+	printLine(0, fmt.Sprintf("> %s", p.query), termbox.ColorDefault, termbox.ColorDefault)
+	termbox.SetCursor(len([]rune(p.query))+2, 0)
+	printLine(1, fmt.Sprintf("%d/%d matches, %d selected | space: toggle, ctrl-a: toggle all, enter: confirm, esc: cancel",
+		len(p.matches), len(p.items), len(p.Selection())), termbox.ColorBlue, termbox.ColorDefault)
+
+	rows := h - 2
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if rows > 0 && p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+	for i := 0; i < rows && p.offset+i < len(p.matches); i++ {
+		item := p.matches[p.offset+i]
+		mark := "[ ]"
+		if p.selected[item] {
+			mark = "[x]"
+		}
+		fg, bg := termbox.ColorDefault, termbox.ColorDefault
+		if p.offset+i == p.cursor {
+			fg |= termbox.AttrReverse
+		}
+		printLine(i+2, fmt.Sprintf("%s %s", mark, item), fg, bg)
+	}
+	termbox.Flush()
+}
@@ -0,0 +1,68 @@
+package terraform
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"", "aws_instance.web", true},
+		{"web", "aws_instance.web", true},
+		{"aiw", "aws_instance.web", true},
+		{"AIW", "aws_instance.web", true},
+		{"bw", "aws_instance.web", false},
+		{"webx", "aws_instance.web", false},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.s, func(t *testing.T) {
+			_, ok := fuzzyMatch(test.pattern, test.s)
+			if ok != test.match {
+				t.Errorf("expected %t, got %t", test.match, ok)
+			}
+		})
+	}
+
+	// Word starts and consecutive matches rank higher
+	a, _ := fuzzyMatch("web", "module.app.aws_instance.web")
+	b, _ := fuzzyMatch("web", "aws_lb.w_e_b")
+	if a <= b {
+		t.Errorf("expected consecutive match to score higher: %d <= %d", a, b)
+	}
+}
+
+func TestPicker(t *testing.T) {
+	items := []string{"aws_instance.api", "aws_instance.web", "aws_s3_bucket.logs", "module.web.aws_lb.this"}
+	p := newPicker(items, []string{"aws_s3_bucket.logs", "aws_instance.gone"})
+
+	if !slices.Equal(p.Selection(), []string{"aws_s3_bucket.logs"}) {
+		t.Errorf("unexpected initial selection: %v", p.Selection())
+	}
+
+	p.query = "web"
+	p.filter()
+	if len(p.matches) != 2 {
+		t.Fatalf("expected 2 matches, got %v", p.matches)
+	}
+	p.toggleAll()
+	expected := []string{"aws_instance.web", "aws_s3_bucket.logs", "module.web.aws_lb.this"}
+	if !slices.Equal(p.Selection(), expected) {
+		t.Errorf("expected %v, got %v", expected, p.Selection())
+	}
+	p.toggleAll()
+	if !slices.Equal(p.Selection(), []string{"aws_s3_bucket.logs"}) {
+		t.Errorf("expected toggle all to deselect, got %v", p.Selection())
+	}
+
+	p.query = ""
+	p.filter()
+	p.move(10)
+	p.toggle()
+	if !slices.Contains(p.Selection(), "module.web.aws_lb.this") {
+		t.Errorf("expected last item selected, got %v", p.Selection())
+	}
+}
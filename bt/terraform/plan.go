@@ -13,7 +13,6 @@ import (
 	"github.com/DavidGamba/dgtools/fsmodtime"
 	"github.com/DavidGamba/dgtools/run"
 	"github.com/DavidGamba/go-getoptions"
-	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/mattn/go-isatty"
 )
 
@@ -29,7 +28,9 @@ func planCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt
 	opt.Bool("ignore-cache", false, opt.Description("ignore the cache and re-run the plan"), opt.Alias("ic"))
 	opt.StringSlice("target", 1, 99)
 	opt.StringSlice("replace", 1, 99)
-	opt.SetCommandFn(planRun)
+	opt.Bool("pick", false, opt.Description("Interactively pick the resources to target from the state and the last plan"))
+	opt.Bool("pick-last", false, opt.Description("Target the resources picked in the previous --pick run"))
+	opt.SetCommandFn(taskFn("plan", resolveBinaryFn(planRun)))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -49,6 +50,8 @@ func planRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 	targets := opt.Value("target").([]string)
 	replacements := opt.Value("replace").([]string)
 	ws := opt.Value("ws").(string)
+	pick := opt.Value("pick").(bool)
+	pickLast := opt.Value("pick-last").(bool)
 
 	cfg := config.ConfigFromContext(ctx)
 	Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
@@ -80,57 +83,26 @@ func planRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 		planFile = fmt.Sprintf(".tf.plan-%s", ws)
 	}
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current dir: %w", err)
-	}
-
-	moduleFiles := []string{}
-	moduleInfo, diags := tfconfig.LoadModule(".")
-	if diags.HasErrors() {
-		return fmt.Errorf("failed to load module: %w", diags)
-	}
-	for module, moduleCall := range moduleInfo.ModuleCalls {
-		source := moduleCall.Source
-		if _, err := os.Stat(filepath.Join(".", source)); os.IsNotExist(err) {
-			Logger.Printf("remote module: %s, %s\n", module, source)
-			continue
-		}
-		// Logger.Printf("local module: %s, %s\n", module, source)
-		// include all files in module dir, these could be included templates or scripts.
-		moduleFiles = append(moduleFiles, filepath.Join(cwd, source, "*"))
-	}
-
-	sources := append(append(append([]string{".tf.init"}, defaultVarFiles...), varFiles...), moduleFiles...)
-	sources = append(sources, "./*") // include all files in current dir, these could be included templates or scripts.
-	relSources := []string{}
-	for _, s := range sources {
-		if strings.HasPrefix(s, "/") {
-			relSources = append(relSources, filepath.Join("./", s))
-		} else {
-			relSources = append(relSources, filepath.Join("./", cwd, s))
+	if pick || pickLast {
+		picked, err := pickTargets(ctx, cfg, profile, ws, planFile, pickLast)
+		if err != nil {
+			return err
 		}
+		targets = append(targets, picked...)
+		// The cached plan might not match the selection
+		ignoreCache = true
 	}
 
-	filteredSources := []string{}
-	globs, _, err := fsmodtime.Glob(os.DirFS("/"), false, relSources)
+	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to glob sources: %w", err)
+		return fmt.Errorf("failed to get current dir: %w", err)
 	}
 
-	for _, g := range globs {
-		// Logger.Printf("glob: %s\n", g)
-		if !strings.Contains(g, "/.tf.plan") &&
-			!strings.Contains(g, "/.tf.check") &&
-			!strings.Contains(g, "/.tf.apply") &&
-			!strings.Contains(g, "/.terraform/") &&
-			!strings.Contains(g, "/.terraform.lock.hcl") {
-			filteredSources = append(filteredSources, g)
-		}
+	filteredSources, err := planSources(cwd, defaultVarFiles, varFiles)
+	if err != nil {
+		return err
 	}
 
-	// Logger.Printf("sources: %v, relSources: %v\n", sources, relSources)
-
 	// fsmodtime.Logger = Logger
 
 	// Paths tested with fs.FS can't start with "/". See https://pkg.go.dev/io/fs#ValidPath
@@ -140,19 +112,30 @@ func planRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 	if err != nil {
 		Logger.Printf("failed to check changes for: '%s'\n", planFile)
 	}
+	if !ignoreCache && !modified {
+		// The sources can be unchanged while git moved on, apply would refuse such a plan
+		p, err := readPlanProvenance(planFile)
+		if err != nil {
+			Logger.Printf("WARNING: %s\n", err)
+			modified = true
+		} else {
+			reasons, err := planCacheReasons(ctx, cfg, profile, p)
+			if err != nil {
+				return err
+			}
+			if len(reasons) > 0 {
+				Logger.Printf("stale plan: %s\n", strings.Join(reasons, "; "))
+				modified = true
+			}
+		}
+	}
 	if !ignoreCache && !modified {
 		Logger.Printf("no changes: skipping plan\n")
+		Events.Skipped("plan", ws, "no changes")
 		return nil
 	}
 	if len(files) > 0 {
-		modifiedFiles := []string{}
-		for _, f := range files {
-			rel, err := filepath.Rel(cwd, "/"+f)
-			if err != nil {
-				rel = f
-			}
-			modifiedFiles = append(modifiedFiles, rel)
-		}
+		modifiedFiles := Events.ModifiedSources("plan", ws, cwd, files)
 		Logger.Printf("modified: %v\n", modifiedFiles)
 	} else {
 		Logger.Printf("missing target: %v\n", planFile)
@@ -190,16 +173,93 @@ func planRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
 		Logger.Printf("export %s\n", wsEnv)
 		ri.Env(wsEnv)
 	}
-	err = ri.Run()
-	if err != nil {
-		// exit code 2 with detailed-exitcode means changes found
-		var eerr *exec.ExitError
-		if detailedExitcode && errors.As(err, &eerr) && eerr.ExitCode() == 2 {
-			Logger.Printf("plan has changes\n")
-			return eerr
-		}
+	runErr := runCMD("plan", ws, ri, cmd)
+	// exit code 2 with detailed-exitcode means changes found
+	var eerr *exec.ExitError
+	hasChanges := detailedExitcode && errors.As(runErr, &eerr) && eerr.ExitCode() == 2
+	if runErr != nil && !hasChanges {
 		os.Remove(planFile)
-		return fmt.Errorf("failed to run: %w", err)
+		os.Remove(provenanceFile(planFile))
+		return fmt.Errorf("failed to run: %w", runErr)
+	}
+
+	err = writePlanProvenance(ctx, profile, ws, planFile, append(defaultVarFiles, varFiles...), filteredSources)
+	if err != nil {
+		return err
+	}
+
+	if Events.Enabled() {
+		emitPlanSummary(ctx, cfg, profile, ws, planFile)
+	}
+
+	if hasChanges {
+		Logger.Printf("plan has changes\n")
+		return eerr
 	}
 	return nil
 }
+
+// emitPlanSummary - Emits the resource change counts of the given plan.
+func emitPlanSummary(ctx context.Context, cfg *config.Config, profile, ws, planFile string) {
+	data, err := showPlanJSON(ctx, cfg, profile, ws, planFile)
+	if err != nil {
+		Logger.Printf("WARNING: %s\n", err)
+		return
+	}
+	plan, err := readPlanJSON(data)
+	if err != nil {
+		Logger.Printf("WARNING: %s\n", err)
+		return
+	}
+	changes := plan.changes()
+	Events.Emit(Event{Type: "plan_summary", Task: "plan", Profile: profile, Workspace: ws, Changes: &changes})
+}
+
+// planSources - Returns the files that invalidate the plan when modified.
+// Paths are relative to "/" so they can be used with os.DirFS("/").
+func planSources(cwd string, defaultVarFiles, varFiles []string) ([]string, error) {
+	moduleFiles := []string{}
+	moduleTree, err := loadModuleTree(".")
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range localModuleDirs(moduleTree) {
+		// include all files in module dir, these could be included templates or scripts.
+		moduleFiles = append(moduleFiles, filepath.Join(dir, "*"))
+	}
+
+	sources := append(append(append([]string{}, defaultVarFiles...), varFiles...), moduleFiles...)
+	sources = append(sources, "./*") // include all files in current dir, these could be included templates or scripts.
+	relSources := []string{}
+	for _, s := range sources {
+		if strings.HasPrefix(s, "/") {
+			relSources = append(relSources, filepath.Join("./", s))
+		} else {
+			relSources = append(relSources, filepath.Join("./", cwd, s))
+		}
+	}
+
+	filteredSources := []string{}
+	globs, _, err := fsmodtime.Glob(os.DirFS("/"), false, relSources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob sources: %w", err)
+	}
+
+	for _, g := range globs {
+		// Logger.Printf("glob: %s\n", g)
+		// .tf.init is rewritten when init runs for another workspace so it would invalidate the plans of every workspace.
+		if !strings.Contains(g, "/.tf.init") &&
+			!strings.Contains(g, "/.tf.plan") &&
+			!strings.Contains(g, "/.tf.pick") &&
+			!strings.Contains(g, "/.tf.check") &&
+			!strings.Contains(g, "/.tf.apply") &&
+			!strings.Contains(g, "/.terraform/") &&
+			!strings.Contains(g, "/.terraform.lock.hcl") {
+			filteredSources = append(filteredSources, g)
+		}
+	}
+
+	// Logger.Printf("sources: %v, relSources: %v\n", sources, relSources)
+
+	return filteredSources, nil
+}
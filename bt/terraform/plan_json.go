@@ -0,0 +1,93 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/dgtools/run"
+)
+
+// jsonPlan - Subset of the `terraform show -json <plan>` output.
+// See https://developer.hashicorp.com/terraform/internals/json-format
+type jsonPlan struct {
+	FormatVersion   string           `json:"format_version"`
+	ResourceChanges []resourceChange `json:"resource_changes"`
+}
+
+type resourceChange struct {
+	Address       string `json:"address"`
+	ModuleAddress string `json:"module_address"`
+	Mode          string `json:"mode"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	ProviderName  string `json:"provider_name"`
+	Change        struct {
+		Actions      []string `json:"actions"`
+		Before       any      `json:"before"`
+		After        any      `json:"after"`
+		AfterUnknown any      `json:"after_unknown"`
+	} `json:"change"`
+}
+
+func (rc resourceChange) is(actions ...string) bool {
+	return slices.Equal(rc.Change.Actions, actions)
+}
+
+// planChanges - Resource change counts as shown in the plan summary.
+type planChanges struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+}
+
+// changes - Counts managed resource changes, replacements count as both add and destroy.
+func (p *jsonPlan) changes() planChanges {
+	c := planChanges{}
+	for _, rc := range p.ResourceChanges {
+		if rc.Mode != "managed" {
+			continue
+		}
+		switch {
+		case rc.is("create"):
+			c.Add++
+		case rc.is("update"):
+			c.Change++
+		case rc.is("delete"):
+			c.Destroy++
+		case rc.is("delete", "create"), rc.is("create", "delete"):
+			c.Add++
+			c.Destroy++
+		}
+	}
+	return c
+}
+
+func readPlanJSON(data []byte) (*jsonPlan, error) {
+	plan := &jsonPlan{}
+	err := json.Unmarshal(data, plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json plan: %w", err)
+	}
+	return plan, nil
+}
+
+// showPlanJSON - Renders the given plan file in json format.
+func showPlanJSON(ctx context.Context, cfg *config.Config, profile, ws, planFile string) ([]byte, error) {
+	cmd := []string{cfg.TFProfile[profile].BinaryName, "show", "-json", planFile}
+	dataDir := fmt.Sprintf("TF_DATA_DIR=%s", getDataDir(cfg.Config.DefaultTerraformProfile, profile))
+	Logger.Printf("export %s\n", dataDir)
+	ri := run.CMD(cmd...).Ctx(ctx).Stdin().Log().Env(dataDir)
+	if ws != "" {
+		wsEnv := fmt.Sprintf("TF_WORKSPACE=%s", ws)
+		Logger.Printf("export %s\n", wsEnv)
+		ri.Env(wsEnv)
+	}
+	out, err := ri.STDOutOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan json output: %w", err)
+	}
+	return out, nil
+}
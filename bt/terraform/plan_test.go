@@ -0,0 +1,41 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPlanSources(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"main.tf", "dev.tfvars", ".tf.init", ".tf.plan-dev", ".tf.plan-dev.meta", ".tf.check-dev", ".tf.apply-dev", ".tf.pick-dev"} {
+		err := os.WriteFile(filepath.Join(dir, f), []byte(""), 0644)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.Chdir(cwd)
+
+	sources, err := planSources(dir, []string{}, []string{"dev.tfvars"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	slices.Sort(sources)
+	sources = slices.Compact(sources)
+	expected := []string{
+		filepath.Join("./", dir, "dev.tfvars"),
+		filepath.Join("./", dir, "main.tf"),
+	}
+	if !slices.Equal(sources, expected) {
+		t.Errorf("expected %v, got %v", expected, sources)
+	}
+}
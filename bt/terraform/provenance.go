@@ -0,0 +1,241 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/dgtools/run"
+)
+
+// planProvenance - Records where and when a plan was generated.
+// It is saved next to the plan file so apply can refuse stale plans.
+type planProvenance struct {
+	// Empty when not running inside a git repo
+	GitHead   string    `json:"git_head"`
+	GitDirty  bool      `json:"git_dirty"`
+	CreatedAt time.Time `json:"created_at"`
+	Profile   string    `json:"profile"`
+	Workspace string    `json:"workspace"`
+	VarFiles  []string  `json:"var_files"`
+}
+
+func provenanceFile(planFile string) string {
+	return planFile + ".meta"
+}
+
+// writePlanProvenance - Saves the provenance of the given plan file.
+// sources are the plan sources as returned by planSources.
+func writePlanProvenance(ctx context.Context, profile, ws, planFile string, varFiles, sources []string) error {
+	dirty, err := gitDirtyFiles(ctx, sources)
+	if err != nil {
+		return err
+	}
+	p := planProvenance{
+		GitHead:   gitHead(ctx),
+		GitDirty:  len(dirty) > 0,
+		CreatedAt: time.Now().UTC(),
+		Profile:   profile,
+		Workspace: ws,
+		VarFiles:  varFiles,
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan provenance: %w", err)
+	}
+	err = os.WriteFile(provenanceFile(planFile), data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write plan provenance: %w", err)
+	}
+	return nil
+}
+
+func readPlanProvenance(planFile string) (*planProvenance, error) {
+	data, err := os.ReadFile(provenanceFile(planFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan provenance: %w", err)
+	}
+	p := &planProvenance{}
+	err = json.Unmarshal(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse plan provenance: %w", err)
+	}
+	return p, nil
+}
+
+// checkPlanProvenance - Returns an error if the plan is stale and shouldn't be applied.
+func checkPlanProvenance(ctx context.Context, cfg *config.Config, profile, planFile string) error {
+	p, err := readPlanProvenance(planFile)
+	if err != nil {
+		return fmt.Errorf("plan provenance not found for '%s', re-run the plan with --ignore-cache or use --force-stale: %w", planFile, err)
+	}
+	Logger.Printf("plan: %s, created at: %s, git head: %s, dirty: %t\n", planFile, p.CreatedAt.Local().Format(time.RFC3339), p.GitHead, p.GitDirty)
+
+	reasons, err := planStaleReasons(ctx, cfg, profile, p)
+	if err != nil {
+		return err
+	}
+	if len(reasons) > 0 {
+		return fmt.Errorf("refusing to apply stale plan '%s', re-run the plan with --ignore-cache or use --force-stale to override: %s", planFile, strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// planStaleReasons - Lists the reasons why a plan with the given provenance is stale using the profile apply settings.
+func planStaleReasons(ctx context.Context, cfg *config.Config, profile string, p *planProvenance) ([]string, error) {
+	maxAge, err := maxPlanAge(cfg, profile)
+	if err != nil {
+		return nil, err
+	}
+	allowDirty := cfg.TFProfile[profile].Apply.AllowDirty
+	dirty := []string{}
+	if !allowDirty {
+		dirty, err = provenanceDirtyFiles(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return staleReasons(p, profile, gitHead(ctx), dirty, allowDirty, maxAge, time.Now()), nil
+}
+
+// planCacheReasons - Lists the reasons why a cached plan with the given provenance must be regenerated even when its sources didn't change.
+// Uncommitted changes only count when they differ from the ones the plan was generated with, the modification times cover the rest.
+func planCacheReasons(ctx context.Context, cfg *config.Config, profile string, p *planProvenance) ([]string, error) {
+	maxAge, err := maxPlanAge(cfg, profile)
+	if err != nil {
+		return nil, err
+	}
+	dirty, err := provenanceDirtyFiles(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return cacheReasons(p, profile, gitHead(ctx), dirty, maxAge, time.Now()), nil
+}
+
+// cacheReasons - Lists the reasons why a cached plan with the given provenance must be regenerated.
+// dirty are the sources with uncommitted changes now, a maxAge of 0 disables the age check.
+func cacheReasons(p *planProvenance, profile, head string, dirty []string, maxAge time.Duration, now time.Time) []string {
+	reasons := staleReasons(p, profile, head, nil, true, maxAge, now)
+	if p.GitDirty && len(dirty) == 0 {
+		reasons = append(reasons, "uncommitted changes were reverted or committed")
+	}
+	if !p.GitDirty && len(dirty) > 0 {
+		reasons = append(reasons, fmt.Sprintf("uncommitted changes in %v", dirty))
+	}
+	return reasons
+}
+
+func maxPlanAge(cfg *config.Config, profile string) (time.Duration, error) {
+	if cfg.TFProfile[profile].Apply.MaxPlanAge == "" {
+		return 0, nil
+	}
+	maxAge, err := parseAge(cfg.TFProfile[profile].Apply.MaxPlanAge)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse max_plan_age: %w", err)
+	}
+	return maxAge, nil
+}
+
+// provenanceDirtyFiles - Returns the sources of the plan with the given provenance that have uncommitted changes.
+func provenanceDirtyFiles(ctx context.Context, p *planProvenance) ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current dir: %w", err)
+	}
+	sources, err := planSources(cwd, []string{}, p.VarFiles)
+	if err != nil {
+		return nil, err
+	}
+	return gitDirtyFiles(ctx, sources)
+}
+
+// staleReasons - Lists the reasons why a plan with the given provenance shouldn't be applied.
+// dirty are the sources with uncommitted changes now, allowDirty skips the checks for uncommitted changes.
+// A maxAge of 0 disables the age check.
+func staleReasons(p *planProvenance, profile, head string, dirty []string, allowDirty bool, maxAge time.Duration, now time.Time) []string {
+	reasons := []string{}
+	if p.Profile != profile {
+		reasons = append(reasons, fmt.Sprintf("plan generated with profile '%s'", p.Profile))
+	}
+	if p.GitHead != "" && head != p.GitHead {
+		reasons = append(reasons, fmt.Sprintf("git HEAD moved from %s to %s", shortSHA(p.GitHead), shortSHA(head)))
+	}
+	if !allowDirty && len(dirty) > 0 {
+		reasons = append(reasons, fmt.Sprintf("uncommitted changes in %v", dirty))
+	}
+	// The uncommitted changes the plan was generated from can't be verified
+	if !allowDirty && p.GitDirty {
+		reasons = append(reasons, "plan generated with uncommitted changes")
+	}
+	if maxAge > 0 && now.Sub(p.CreatedAt) > maxAge {
+		reasons = append(reasons, fmt.Sprintf("plan is %s old, max age is %s", humanAge(now.Sub(p.CreatedAt)), humanAge(maxAge)))
+	}
+	return reasons
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// gitHead - Returns the current git commit or an empty string when not in a git repo.
+func gitHead(ctx context.Context) string {
+	out, err := run.CMD("git", "rev-parse", "HEAD").Ctx(ctx).DiscardErr().STDOutOutput()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// gitDirtyFiles - Returns the sources with uncommitted changes, including untracked files.
+// sources are relative to "/" as returned by planSources.
+// Sources outside of the git repo and bt artifacts are ignored.
+func gitDirtyFiles(ctx context.Context, sources []string) ([]string, error) {
+	out, err := run.CMD("git", "rev-parse", "--show-toplevel").Ctx(ctx).DiscardErr().STDOutOutput()
+	if err != nil {
+		// Not in a git repo
+		return []string{}, nil
+	}
+	top := strings.TrimSpace(string(out))
+
+	paths := []string{}
+	for _, s := range sources {
+		s = "/" + s
+		if !strings.HasPrefix(s, top+"/") || strings.HasPrefix(filepath.Base(s), ".tf.") {
+			continue
+		}
+		paths = append(paths, s)
+	}
+	if len(paths) == 0 {
+		return []string{}, nil
+	}
+
+	cmd := append([]string{"git", "status", "--porcelain", "--untracked-files=all", "--"}, paths...)
+	out, err = run.CMD(cmd...).Ctx(ctx).Dir(top).SaveErr().STDOutOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+	dirty := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		f := line[3:]
+		// Renames are shown as "old -> new"
+		if _, after, ok := strings.Cut(f, " -> "); ok {
+			f = after
+		}
+		if !slices.Contains(dirty, f) {
+			dirty = append(dirty, f)
+		}
+	}
+	return dirty, nil
+}
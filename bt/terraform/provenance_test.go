@@ -0,0 +1,87 @@
+package terraform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleReasons(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	p := &planProvenance{
+		GitHead:   "0123456789abcdef",
+		CreatedAt: now.Add(-2 * time.Hour),
+		Profile:   "default",
+	}
+	tests := []struct {
+		name     string
+		profile  string
+		head     string
+		dirty    []string
+		maxAge   time.Duration
+		expected int
+	}{
+		{"fresh", "default", "0123456789abcdef", nil, 0, 0},
+		{"fresh within max age", "default", "0123456789abcdef", nil, 3 * time.Hour, 0},
+		{"too old", "default", "0123456789abcdef", nil, time.Hour, 1},
+		{"head moved", "default", "fedcba9876543210", nil, 0, 1},
+		{"dirty", "default", "0123456789abcdef", []string{"main.tf"}, 0, 1},
+		{"other profile", "prod", "0123456789abcdef", nil, 0, 1},
+		{"all", "prod", "fedcba9876543210", []string{"main.tf"}, time.Hour, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reasons := staleReasons(p, test.profile, test.head, test.dirty, false, test.maxAge, now)
+			if len(reasons) != test.expected {
+				t.Errorf("expected %d reasons, got %d: %v", test.expected, len(reasons), reasons)
+			}
+		})
+	}
+
+	t.Run("outside git repo", func(t *testing.T) {
+		reasons := staleReasons(&planProvenance{Profile: "default", CreatedAt: now}, "default", "", nil, false, 0, now)
+		if len(reasons) != 0 {
+			t.Errorf("expected no reasons, got %v", reasons)
+		}
+	})
+
+	t.Run("plan generated dirty", func(t *testing.T) {
+		dirtyPlan := &planProvenance{GitHead: "0123456789abcdef", GitDirty: true, Profile: "default", CreatedAt: now}
+		reasons := staleReasons(dirtyPlan, "default", "0123456789abcdef", nil, false, 0, now)
+		if len(reasons) != 1 {
+			t.Errorf("expected 1 reason, got %v", reasons)
+		}
+		reasons = staleReasons(dirtyPlan, "default", "0123456789abcdef", []string{"main.tf"}, true, 0, now)
+		if len(reasons) != 0 {
+			t.Errorf("expected no reasons with allow dirty, got %v", reasons)
+		}
+	})
+}
+
+func TestCacheReasons(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	clean := &planProvenance{GitHead: "0123456789abcdef", Profile: "default", CreatedAt: now.Add(-2 * time.Hour)}
+	dirty := &planProvenance{GitHead: "0123456789abcdef", GitDirty: true, Profile: "default", CreatedAt: now.Add(-2 * time.Hour)}
+	tests := []struct {
+		name     string
+		p        *planProvenance
+		head     string
+		dirty    []string
+		maxAge   time.Duration
+		expected int
+	}{
+		{"fresh", clean, "0123456789abcdef", nil, 0, 0},
+		{"still dirty", dirty, "0123456789abcdef", []string{"main.tf"}, 0, 0},
+		{"head moved", clean, "fedcba9876543210", nil, 0, 1},
+		{"too old", clean, "0123456789abcdef", nil, time.Hour, 1},
+		{"became dirty", clean, "0123456789abcdef", []string{"main.tf"}, 0, 1},
+		{"dirty changes committed", dirty, "fedcba9876543210", nil, 0, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reasons := cacheReasons(test.p, "default", test.head, test.dirty, test.maxAge, now)
+			if len(reasons) != test.expected {
+				t.Errorf("expected %d reasons, got %d: %v", test.expected, len(reasons), reasons)
+			}
+		})
+	}
+}
@@ -13,7 +13,7 @@ func refreshCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetO
 
 	opt := parent.NewCommand("refresh", "")
 	opt.StringSlice("var-file", 1, 1)
-	opt.SetCommandFn(refreshRun)
+	opt.SetCommandFn(resolveBinaryFn(refreshRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -13,7 +13,7 @@ func showCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("show", "")
-	opt.SetCommandFn(showRun)
+	opt.SetCommandFn(resolveBinaryFn(showRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -17,7 +17,7 @@ func showPlanCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.Get
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("show-plan", "")
-	opt.SetCommandFn(showPlanRun)
+	opt.SetCommandFn(resolveBinaryFn(showPlanRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -67,7 +67,7 @@ func showPlanRun(ctx context.Context, opt *getoptions.GetOpt, args []string) err
 		Logger.Printf("export %s\n", wsEnv)
 		ri.Env(wsEnv)
 	}
-	err = ri.Run()
+	err = runCMD("show", ws, ri, cmd)
 	if err != nil {
 		return fmt.Errorf("failed to run: %w", err)
 	}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strings"
 
 	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/dgtools/run"
 	"github.com/DavidGamba/go-getoptions"
 )
 
@@ -15,7 +17,7 @@ func stateListCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.Ge
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("state-list", "")
-	opt.SetCommandFn(stateListRun)
+	opt.SetCommandFn(resolveBinaryFn(stateListRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -35,12 +37,33 @@ func stateListRun(ctx context.Context, opt *getoptions.GetOpt, args []string) er
 	return wsCMDRun(cmd...)(ctx, opt, args)
 }
 
+// stateAddresses - Returns the resource addresses in the state of the given workspace.
+func stateAddresses(ctx context.Context, cfg *config.Config, profile, ws string) ([]string, error) {
+	addresses := []string{}
+	cmd := []string{cfg.TFProfile[profile].BinaryName, "state", "list"}
+	dataDir := fmt.Sprintf("TF_DATA_DIR=%s", getDataDir(cfg.Config.DefaultTerraformProfile, profile))
+	ri := run.CMD(cmd...).Ctx(ctx).Log().Env(dataDir)
+	if ws != "" {
+		ri.Env(fmt.Sprintf("TF_WORKSPACE=%s", ws))
+	}
+	out, err := ri.STDOutOutput()
+	if err != nil {
+		return addresses, fmt.Errorf("failed to list state: %w", err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			addresses = append(addresses, line)
+		}
+	}
+	return addresses, nil
+}
+
 func statePushCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
 	profile := parent.Value("profile").(string)
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("state-push", "")
-	opt.SetCommandFn(statePushRun)
+	opt.SetCommandFn(resolveBinaryFn(statePushRun))
 	opt.HelpSynopsisArg("<state_file>", "State file to push")
 
 	wss, err := validWorkspaces(cfg, profile)
@@ -74,7 +97,7 @@ func statePullCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.Ge
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("state-pull", "")
-	opt.SetCommandFn(statePullRun)
+	opt.SetCommandFn(resolveBinaryFn(statePullRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -99,7 +122,7 @@ func stateRMCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetO
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("state-rm", "")
-	opt.SetCommandFn(stateRMRun)
+	opt.SetCommandFn(resolveBinaryFn(stateRMRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -124,7 +147,7 @@ func stateShowCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.Ge
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("state-show", "")
-	opt.SetCommandFn(stateShowRun)
+	opt.SetCommandFn(resolveBinaryFn(stateShowRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -0,0 +1,265 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/go-getoptions"
+)
+
+type moveSuggestion struct {
+	From  string
+	To    string
+	Score float64
+}
+
+func suggestMovesCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
+	profile := parent.Value("profile").(string)
+
+	cfg := config.ConfigFromContext(ctx)
+
+	opt := parent.NewCommand("suggest-moves", "Suggest moved blocks from destroy/create pairs in the latest plan")
+	opt.String("out-file", "-", opt.Description("File to write the suggestions to, use - for stdout"), opt.Alias("o"))
+	opt.Bool("force", false, opt.Description("Overwrite the output file if it exists"))
+	opt.String("format", "moved", opt.ValidValues("moved", "state-mv"), opt.Description("Write moved blocks or state mv commands"))
+	opt.Float64("min-score", 0.5, opt.Description("Minimum confidence score for a pair to be suggested"))
+	opt.SetCommandFn(resolveBinaryFn(suggestMovesRun))
+
+	wss, err := validWorkspaces(cfg, profile)
+	if err != nil {
+		Logger.Printf("WARNING: failed to list workspaces: %s\n", err)
+	}
+	opt.String("ws", "", opt.ValidValues(wss...), opt.Description("Workspace to use"))
+
+	return opt
+}
+
+func suggestMovesRun(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	profile := opt.Value("profile").(string)
+	ws := opt.Value("ws").(string)
+	outFile := opt.Value("out-file").(string)
+	format := opt.Value("format").(string)
+	minScore := opt.Value("min-score").(float64)
+	force := opt.Value("force").(bool)
+
+	cfg := config.ConfigFromContext(ctx)
+	Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
+
+	ws, err := updateWSIfSelected(cfg.Config.DefaultTerraformProfile, profile, ws)
+	if err != nil {
+		return err
+	}
+
+	if cfg.TFProfile[profile].Workspaces.Enabled {
+		if !workspaceSelected(cfg.Config.DefaultTerraformProfile, profile) {
+			if ws == "" {
+				return fmt.Errorf("running in workspace mode but no workspace selected or --ws given")
+			}
+		}
+	}
+
+	planFile := ""
+	if ws == "" {
+		planFile = ".tf.plan"
+	} else {
+		planFile = fmt.Sprintf(".tf.plan-%s", ws)
+	}
+	if _, err := os.Stat(planFile); os.IsNotExist(err) {
+		return fmt.Errorf("plan file not found, run plan first: %s", planFile)
+	}
+
+	data, err := showPlanJSON(ctx, cfg, profile, ws, planFile)
+	if err != nil {
+		return err
+	}
+	plan, err := readPlanJSON(data)
+	if err != nil {
+		return err
+	}
+
+	suggestions := suggestMoves(plan, minScore)
+	if len(suggestions) == 0 {
+		Logger.Printf("no destroy/create pairs found above min score %.2f\n", minScore)
+		return nil
+	}
+
+	// Scores go to stderr so stdout only has the suggestions
+	for _, s := range suggestions {
+		fmt.Fprintf(os.Stderr, "%.2f  %s -> %s\n", s.Score, s.From, s.To)
+	}
+
+	w := outputWriter()
+	if outFile != "-" {
+		flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if force {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		fh, err := os.OpenFile(outFile, flag, 0644)
+		if err != nil {
+			if os.IsExist(err) {
+				return fmt.Errorf("output file already exists: %s, use --force to overwrite", outFile)
+			}
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer fh.Close()
+		w = fh
+	}
+	switch format {
+	case "state-mv":
+		dataDir := getDataDir(cfg.Config.DefaultTerraformProfile, profile)
+		writeStateMvCommands(w, cfg.TFProfile[profile].BinaryName, dataDir, ws, suggestions)
+	default:
+		writeMovedBlocks(w, suggestions)
+	}
+	if outFile != "-" {
+		Logger.Printf("suggestions written to: %s, review them before applying\n", outFile)
+	}
+	return nil
+}
+
+// suggestMoves - Pairs resources being deleted with resources of the same type being created.
+// Pairs are chosen greedily by highest score.
+func suggestMoves(plan *jsonPlan, minScore float64) []moveSuggestion {
+	deletes := map[string][]resourceChange{}
+	creates := map[string][]resourceChange{}
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode != "managed" {
+			continue
+		}
+		switch {
+		case rc.is("delete"):
+			deletes[rc.Type] = append(deletes[rc.Type], rc)
+		case rc.is("create"):
+			creates[rc.Type] = append(creates[rc.Type], rc)
+		}
+	}
+
+	suggestions := []moveSuggestion{}
+	for t, dd := range deletes {
+		cc, ok := creates[t]
+		if !ok {
+			continue
+		}
+		candidates := []moveSuggestion{}
+		for _, d := range dd {
+			for _, c := range cc {
+				score := moveScore(d, c)
+				if score >= minScore {
+					candidates = append(candidates, moveSuggestion{From: d.Address, To: c.Address, Score: score})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].Score != candidates[j].Score {
+				return candidates[i].Score > candidates[j].Score
+			}
+			if candidates[i].From != candidates[j].From {
+				return candidates[i].From < candidates[j].From
+			}
+			return candidates[i].To < candidates[j].To
+		})
+		usedFrom := map[string]bool{}
+		usedTo := map[string]bool{}
+		for _, c := range candidates {
+			if usedFrom[c.From] || usedTo[c.To] {
+				continue
+			}
+			usedFrom[c.From] = true
+			usedTo[c.To] = true
+			suggestions = append(suggestions, c)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].From < suggestions[j].From
+	})
+	return suggestions
+}
+
+// moveScore - Fraction of attributes with the same value in the deleted and created resource.
+// Attributes only known after apply are ignored.
+// Resources with the same name get a small bonus to break ties.
+func moveScore(d, c resourceChange) float64 {
+	before := map[string]string{}
+	flattenAttributes("", d.Change.Before, before)
+	after := map[string]string{}
+	flattenAttributes("", c.Change.After, after)
+	unknown := map[string]string{}
+	flattenAttributes("", c.Change.AfterUnknown, unknown)
+
+	keys := map[string]bool{}
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	total, matches := 0, 0
+	for k := range keys {
+		if unknown[k] == "true" {
+			continue
+		}
+		total++
+		if bv, ok := before[k]; ok && bv == after[k] {
+			matches++
+		}
+	}
+	score := 0.0
+	if total > 0 {
+		score = float64(matches) / float64(total)
+	}
+	if d.Name == c.Name {
+		score += 0.1
+	}
+	if score > 1 {
+		score = 1
+	}
+	return score
+}
+
+// flattenAttributes - Flattens nested attribute values into a map of path to json encoded leaf values.
+// Null values are skipped.
+func flattenAttributes(prefix string, v any, out map[string]string) {
+	switch t := v.(type) {
+	case nil:
+		return
+	case map[string]any:
+		for k, e := range t {
+			flattenAttributes(prefix+"."+k, e, out)
+		}
+	case []any:
+		for i, e := range t {
+			flattenAttributes(fmt.Sprintf("%s[%d]", prefix, i), e, out)
+		}
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return
+		}
+		out[prefix] = string(b)
+	}
+}
+
+func writeMovedBlocks(w io.Writer, suggestions []moveSuggestion) {
+	blocks := []string{}
+	for _, s := range suggestions {
+		blocks = append(blocks, fmt.Sprintf("# score: %.2f\nmoved {\n  from = %s\n  to   = %s\n}\n", s.Score, s.From, s.To))
+	}
+	fmt.Fprint(w, strings.Join(blocks, "\n"))
+}
+
+func writeStateMvCommands(w io.Writer, binary, dataDir, ws string, suggestions []moveSuggestion) {
+	// Same env bt runs terraform with so the commands target the planned state
+	env := fmt.Sprintf("TF_DATA_DIR=%s", dataDir)
+	if ws != "" {
+		env += fmt.Sprintf(" TF_WORKSPACE=%s", ws)
+	}
+	for _, s := range suggestions {
+		fmt.Fprintf(w, "# score: %.2f\n%s %s state mv '%s' '%s'\n", s.Score, env, binary, s.From, s.To)
+	}
+}
@@ -0,0 +1,95 @@
+package terraform
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSuggestMoves(t *testing.T) {
+	data := []byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.logs",
+      "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+      "change": {"actions": ["delete"], "before": {"bucket": "my-logs", "arn": "arn:aws:s3:::my-logs", "tags": {"team": "a"}}, "after": null}
+    },
+    {
+      "address": "aws_s3_bucket.data",
+      "mode": "managed", "type": "aws_s3_bucket", "name": "data",
+      "change": {"actions": ["delete"], "before": {"bucket": "my-data", "arn": "arn:aws:s3:::my-data", "tags": {"team": "b"}}, "after": null}
+    },
+    {
+      "address": "module.storage.aws_s3_bucket.logs",
+      "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+      "change": {"actions": ["create"], "before": null, "after": {"bucket": "my-logs", "tags": {"team": "a"}}, "after_unknown": {"arn": true}}
+    },
+    {
+      "address": "module.storage.aws_s3_bucket.archive",
+      "mode": "managed", "type": "aws_s3_bucket", "name": "archive",
+      "change": {"actions": ["create"], "before": null, "after": {"bucket": "my-data", "tags": {"team": "b"}}, "after_unknown": {"arn": true}}
+    },
+    {
+      "address": "aws_sqs_queue.q",
+      "mode": "managed", "type": "aws_sqs_queue", "name": "q",
+      "change": {"actions": ["create"], "before": null, "after": {"name": "my-logs"}, "after_unknown": {}}
+    },
+    {
+      "address": "aws_iam_role.r",
+      "mode": "managed", "type": "aws_iam_role", "name": "r",
+      "change": {"actions": ["delete", "create"], "before": {"name": "r"}, "after": {"name": "r"}, "after_unknown": {}}
+    }
+  ]
+}`)
+	plan, err := readPlanJSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	suggestions := suggestMoves(plan, 0.5)
+	expected := []moveSuggestion{
+		{From: "aws_s3_bucket.data", To: "module.storage.aws_s3_bucket.archive", Score: 1},
+		{From: "aws_s3_bucket.logs", To: "module.storage.aws_s3_bucket.logs", Score: 1},
+	}
+	if len(suggestions) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, suggestions)
+	}
+	for i := range expected {
+		if suggestions[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], suggestions[i])
+		}
+	}
+
+	buf := bytes.Buffer{}
+	writeMovedBlocks(&buf, suggestions[:1])
+	expectedBlock := `# score: 1.00
+moved {
+  from = aws_s3_bucket.data
+  to   = module.storage.aws_s3_bucket.archive
+}
+`
+	if buf.String() != expectedBlock {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedBlock, buf.String())
+	}
+
+	buf.Reset()
+	writeStateMvCommands(&buf, "terraform", ".terraform-dev", "dev", suggestions[:1])
+	expectedCmd := `# score: 1.00
+TF_DATA_DIR=.terraform-dev TF_WORKSPACE=dev terraform state mv 'aws_s3_bucket.data' 'module.storage.aws_s3_bucket.archive'
+`
+	if buf.String() != expectedCmd {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedCmd, buf.String())
+	}
+
+	buf.Reset()
+	writeStateMvCommands(&buf, "terraform", ".terraform", "", suggestions[:1])
+	expectedCmd = `# score: 1.00
+TF_DATA_DIR=.terraform terraform state mv 'aws_s3_bucket.data' 'module.storage.aws_s3_bucket.archive'
+`
+	if buf.String() != expectedCmd {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedCmd, buf.String())
+	}
+
+	if len(suggestMoves(plan, 1.1)) != 0 {
+		t.Errorf("expected no suggestions above max score")
+	}
+}
@@ -15,7 +15,7 @@ func taintCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("taint", "")
-	opt.SetCommandFn(taintRun)
+	opt.SetCommandFn(resolveBinaryFn(taintRun))
 	opt.HelpSynopsisArg("<address>", "Address")
 
 	wss, err := validWorkspaces(cfg, profile)
@@ -49,7 +49,7 @@ func untaintCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetO
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("untaint", "")
-	opt.SetCommandFn(untaintRun)
+	opt.SetCommandFn(resolveBinaryFn(untaintRun))
 	opt.HelpSynopsisArg("<address>", "Address")
 
 	wss, err := validWorkspaces(cfg, profile)
@@ -57,6 +57,9 @@ func NewCommand(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetO
 	// Custom
 	buildCMD(ctx, opt)
 	checksCMD(ctx, opt)
+	cacheCMD(ctx, opt)
+	depsCMD(ctx, opt)
+	suggestMovesCMD(ctx, opt)
 
 	return opt
 }
@@ -67,32 +67,11 @@ func varFileCMDRun(fn VarFileCMDer, cmd ...string) getoptions.CommandFn {
 		cfg := config.ConfigFromContext(ctx)
 		Logger.Printf("cfg: %s\n", cfg.TFProfile[profile])
 
-		ws, err := updateWSIfSelected(cfg.Config.DefaultTerraformProfile, profile, ws)
+		ws, varFileArgs, err := resolveVarFiles(cfg, profile, ws, varFiles)
 		if err != nil {
 			return err
 		}
-
-		ws, err = getWorkspace(cfg, profile, ws, varFiles)
-		if err != nil {
-			return err
-		}
-
-		defaultVarFiles, err := getDefaultVarFiles(cfg, profile)
-		if err != nil {
-			return err
-		}
-
-		varFiles, err = AddVarFileIfWorkspaceSelected(cfg, profile, ws, varFiles)
-		if err != nil {
-			return err
-		}
-
-		for _, v := range defaultVarFiles {
-			cmd = append(cmd, "-var-file", v)
-		}
-		for _, v := range varFiles {
-			cmd = append(cmd, "-var-file", v)
-		}
+		cmd = append(cmd, varFileArgs...)
 		if !isatty.IsTerminal(os.Stdout.Fd()) {
 			cmd = append(cmd, "-no-color")
 		}
@@ -107,7 +86,7 @@ func varFileCMDRun(fn VarFileCMDer, cmd ...string) getoptions.CommandFn {
 			Logger.Printf("export %s\n", wsEnv)
 			ri.Env(wsEnv)
 		}
-		err = ri.Run()
+		err = runCMD(cmd[1], ws, ri, cmd)
 		if err != nil {
 			fn.errorFunction(ws)
 			return fmt.Errorf("failed to run: %w", err)
@@ -116,3 +95,35 @@ func varFileCMDRun(fn VarFileCMDer, cmd ...string) getoptions.CommandFn {
 		return nil
 	}
 }
+
+// resolveVarFiles - Returns the workspace to use and the -var-file args for the default var files, the given var files and the workspace var file.
+func resolveVarFiles(cfg *config.Config, profile, ws string, varFiles []string) (string, []string, error) {
+	args := []string{}
+	ws, err := updateWSIfSelected(cfg.Config.DefaultTerraformProfile, profile, ws)
+	if err != nil {
+		return ws, args, err
+	}
+
+	ws, err = getWorkspace(cfg, profile, ws, varFiles)
+	if err != nil {
+		return ws, args, err
+	}
+
+	defaultVarFiles, err := getDefaultVarFiles(cfg, profile)
+	if err != nil {
+		return ws, args, err
+	}
+
+	varFiles, err = AddVarFileIfWorkspaceSelected(cfg, profile, ws, varFiles)
+	if err != nil {
+		return ws, args, err
+	}
+
+	for _, v := range defaultVarFiles {
+		args = append(args, "-var-file", v)
+	}
+	for _, v := range varFiles {
+		args = append(args, "-var-file", v)
+	}
+	return ws, args, nil
+}
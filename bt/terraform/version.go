@@ -0,0 +1,247 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+	"github.com/DavidGamba/dgtools/fsmodtime"
+	"github.com/DavidGamba/go-getoptions"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+// version - A terraform or tofu version like 1.5.7 or 1.6.0-beta1.
+type version struct {
+	Segments   [3]int
+	Prerelease string
+}
+
+func parseVersion(s string) (version, error) {
+	v := version{}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, v.Prerelease, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 || s == "" {
+		return v, fmt.Errorf("invalid version: '%s'", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version: '%s'", s)
+		}
+		v.Segments[i] = n
+	}
+	return v, nil
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Segments[0], v.Segments[1], v.Segments[2])
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// compare - Returns -1, 0 or 1. Prereleases sort before the release.
+func (v version) compare(o version) int {
+	for i := range v.Segments {
+		if v.Segments[i] != o.Segments[i] {
+			if v.Segments[i] < o.Segments[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	case v.Prerelease < o.Prerelease:
+		return -1
+	}
+	return 1
+}
+
+// versionConstraint - A single required_version constraint like ">= 1.5" or "~> 1.6.0".
+type versionConstraint struct {
+	Op      string
+	Version version
+	// Number of segments given, used by ~>
+	Segments int
+}
+
+// parseConstraints - Parses a comma separated required_version constraint string.
+func parseConstraints(s string) ([]versionConstraint, error) {
+	constraints := []versionConstraint{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		op := "="
+		for _, o := range []string{"~>", ">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(part, o) {
+				op = o
+				part = strings.TrimSpace(strings.TrimPrefix(part, o))
+				break
+			}
+		}
+		v, err := parseVersion(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint '%s': %w", s, err)
+		}
+		segments := len(strings.Split(strings.SplitN(part, "-", 2)[0], "."))
+		constraints = append(constraints, versionConstraint{Op: op, Version: v, Segments: segments})
+	}
+	return constraints, nil
+}
+
+func (c versionConstraint) check(v version) bool {
+	// Prereleases only match when explicitly requested
+	if v.Prerelease != "" && (c.Version.Prerelease == "" || v.Segments != c.Version.Segments) {
+		return false
+	}
+	cmp := v.compare(c.Version)
+	switch c.Op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		if cmp < 0 {
+			return false
+		}
+		// ~> 1.2.3 allows 1.2.x, ~> 1.2 allows 1.x
+		upper := version{}
+		i := c.Segments - 2
+		if i < 0 {
+			i = 0
+		}
+		copy(upper.Segments[:], c.Version.Segments[:i])
+		upper.Segments[i] = c.Version.Segments[i] + 1
+		return v.compare(upper) < 0
+	}
+	return false
+}
+
+// matchConstraints - Returns whether the version matches all the required_version constraints.
+func matchConstraints(v version, requiredVersions []string) (bool, error) {
+	for _, rv := range requiredVersions {
+		constraints, err := parseConstraints(rv)
+		if err != nil {
+			return false, err
+		}
+		for _, c := range constraints {
+			if !c.check(v) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// installedVersions - Lists the <binary>_<version> files in dir sorted newest first.
+func installedVersions(dir, binary string) (map[string]version, []string, error) {
+	versions := map[string]version{}
+	names := []string{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return versions, names, fmt.Errorf("failed to read binary dir: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), binary+"_") {
+			continue
+		}
+		v, err := parseVersion(strings.TrimPrefix(e.Name(), binary+"_"))
+		if err != nil {
+			continue
+		}
+		versions[e.Name()] = v
+		names = append(names, e.Name())
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return versions[names[i]].compare(versions[names[j]]) > 0
+	})
+	return versions, names, nil
+}
+
+// resolveBinaryFn - Wraps the given command resolving the profile binary before running it.
+// The binary is resolved inside the commands so a required_version mismatch doesn't break help or the config commands.
+func resolveBinaryFn(fn getoptions.CommandFn) getoptions.CommandFn {
+	return func(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+		cfg := config.ConfigFromContext(ctx)
+		err := ResolveBinary(cfg, opt.Value("profile").(string))
+		if err != nil {
+			return err
+		}
+		return fn(ctx, opt, args)
+	}
+}
+
+// ResolveBinary - Picks the newest binary in the configured binary dir matching the required_version of the module in the current dir.
+// The profile binary is left unchanged when the module has no required_version or there are no versions installed in the binary dir.
+func ResolveBinary(cfg *config.Config, profile string) error {
+	p, ok := cfg.TFProfile[profile]
+	if !ok || cfg.Config.BinaryDir == "" {
+		return nil
+	}
+
+	module, diags := tfconfig.LoadModule(".")
+	if diags.HasErrors() {
+		// Let the binary report the errors
+		Logger.Printf("WARNING: failed to load module: %s\n", diags)
+		return nil
+	}
+	if len(module.RequiredCore) == 0 {
+		return nil
+	}
+
+	dd, err := fsmodtime.ExpandEnv([]string{cfg.Config.BinaryDir})
+	if err != nil {
+		return fmt.Errorf("failed to expand binary dir: %w", err)
+	}
+	dir := dd[0]
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+
+	binary := filepath.Base(p.BinaryName)
+	versions, names, err := installedVersions(dir, binary)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	for _, name := range names {
+		match, err := matchConstraints(versions[name], module.RequiredCore)
+		if err != nil {
+			return err
+		}
+		if match {
+			p.BinaryName = filepath.Join(dir, name)
+			p.BinaryVersion = versions[name].String()
+			cfg.TFProfile[profile] = p
+			Logger.Printf("required_version: %q, using: %s\n", module.RequiredCore, p.BinaryName)
+			return nil
+		}
+	}
+	installed := []string{}
+	for _, name := range names {
+		installed = append(installed, versions[name].String())
+	}
+	return fmt.Errorf("no %s version in '%s' matches required_version %q, installed versions: %v", binary, dir, module.RequiredCore, installed)
+}
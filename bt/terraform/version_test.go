@@ -0,0 +1,112 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DavidGamba/dgtools/bt/config"
+)
+
+func TestMatchConstraints(t *testing.T) {
+	tests := []struct {
+		version     string
+		constraints []string
+		expected    bool
+	}{
+		{"1.5.7", []string{">= 1.5"}, true},
+		{"1.4.0", []string{">= 1.5"}, false},
+		{"1.5.7", []string{"1.5.7"}, true},
+		{"1.5.7", []string{"= 1.5.6"}, false},
+		{"1.5.7", []string{"!= 1.5.7"}, false},
+		{"1.5.7", []string{"~> 1.5.0"}, true},
+		{"1.6.0", []string{"~> 1.5.0"}, false},
+		{"1.9.0", []string{"~> 1.5"}, true},
+		{"2.0.0", []string{"~> 1.5"}, false},
+		{"1.9.0", []string{"~> 1"}, true},
+		{"1.5.7", []string{">= 1.2, < 1.6"}, true},
+		{"1.6.0", []string{">= 1.2, < 1.6"}, false},
+		{"1.6.0", []string{">= 1.2", "< 1.6"}, false},
+		{"1.6.0-beta1", []string{">= 1.5"}, false},
+		{"1.6.0-beta1", []string{"1.6.0-beta1"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.version+" "+strings.Join(test.constraints, " "), func(t *testing.T) {
+			v, err := parseVersion(test.version)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := matchConstraints(v, test.constraints)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, got)
+			}
+		})
+	}
+
+	_, err := matchConstraints(version{}, []string{">= one"})
+	if err == nil {
+		t.Errorf("expected error for invalid constraint")
+	}
+}
+
+func TestResolveBinary(t *testing.T) {
+	binDir := t.TempDir()
+	for _, name := range []string{"terraform_1.4.6", "terraform_1.5.7", "terraform_1.5.2", "terraform_1.6.0-rc1", "tofu_1.6.0"} {
+		err := os.WriteFile(filepath.Join(binDir, name), []byte(""), 0755)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	newCfg := func() *config.Config {
+		cfg := &config.Config{TFProfile: map[string]config.TerraformProfile{"default": {BinaryName: "terraform"}}}
+		cfg.Config.BinaryDir = binDir
+		return cfg
+	}
+
+	tests := []struct {
+		name            string
+		requiredVersion string
+		expected        string
+		err             bool
+	}{
+		{"newest match", "~> 1.5.0", "1.5.7", false},
+		{"upper bound", ">= 1.4, < 1.5.5", "1.5.2", false},
+		{"no match", ">= 1.7", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tf := "terraform {\n  required_version = \"" + test.requiredVersion + "\"\n}\n"
+			err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0644)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			err = os.Chdir(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer os.Chdir(cwd)
+
+			cfg := newCfg()
+			err = ResolveBinary(cfg, "default")
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.TFProfile["default"].BinaryVersion != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, cfg.TFProfile["default"].BinaryVersion)
+			}
+			if !test.err && cfg.TFProfile["default"].BinaryName != filepath.Join(binDir, "terraform_"+test.expected) {
+				t.Errorf("unexpected binary: %s", cfg.TFProfile["default"].BinaryName)
+			}
+		})
+	}
+}
@@ -78,7 +78,7 @@ func visualizePlanCMD(ctx context.Context, parent *getoptions.GetOpt) *getoption
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("visualize-plan", "")
-	opt.SetCommandFn(visualizePlanRun)
+	opt.SetCommandFn(resolveBinaryFn(visualizePlanRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -185,7 +185,7 @@ func visualizePlanRun(ctx context.Context, opt *getoptions.GetOpt, args []string
 		return fmt.Errorf("failed to pull image: %w", err)
 	}
 	defer out.Close()
-	io.Copy(os.Stdout, out)
+	io.Copy(outputWriter(), out)
 
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -245,7 +245,7 @@ func visualizePlanRun(ctx context.Context, opt *getoptions.GetOpt, args []string
 	}
 
 	// Copy the container's output to the program's output=
-	_, err = io.Copy(os.Stdout, hijackedResponse.Reader)
+	_, err = io.Copy(outputWriter(), hijackedResponse.Reader)
 	if err != nil {
 		return fmt.Errorf("failed to copy container's output to stdout: %w", err)
 	}
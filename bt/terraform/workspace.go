@@ -14,7 +14,7 @@ import (
 
 func workspaceListCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
 	opt := parent.NewCommand("workspace-list", "")
-	opt.SetCommandFn(workspaceListRun)
+	opt.SetCommandFn(resolveBinaryFn(workspaceListRun))
 
 	return opt
 }
@@ -30,7 +30,7 @@ func workspaceListRun(ctx context.Context, opt *getoptions.GetOpt, args []string
 
 func workspaceShowCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
 	opt := parent.NewCommand("workspace-show", "")
-	opt.SetCommandFn(workspaceShowRun)
+	opt.SetCommandFn(resolveBinaryFn(workspaceShowRun))
 
 	return opt
 }
@@ -50,7 +50,7 @@ func workspaceSelectCMD(ctx context.Context, parent *getoptions.GetOpt) *getopti
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("workspace-select", "")
-	opt.SetCommandFn(workspaceSelectRun)
+	opt.SetCommandFn(resolveBinaryFn(workspaceSelectRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -103,7 +103,7 @@ func workspaceDeleteCMD(ctx context.Context, parent *getoptions.GetOpt) *getopti
 	cfg := config.ConfigFromContext(ctx)
 
 	opt := parent.NewCommand("workspace-delete", "")
-	opt.SetCommandFn(workspaceDeleteRun)
+	opt.SetCommandFn(resolveBinaryFn(workspaceDeleteRun))
 
 	wss, err := validWorkspaces(cfg, profile)
 	if err != nil {
@@ -126,7 +126,7 @@ func workspaceDeleteRun(ctx context.Context, opt *getoptions.GetOpt, args []stri
 
 func workspaceNewCMD(ctx context.Context, parent *getoptions.GetOpt) *getoptions.GetOpt {
 	opt := parent.NewCommand("workspace-new", "")
-	opt.SetCommandFn(workspaceNewRun)
+	opt.SetCommandFn(resolveBinaryFn(workspaceNewRun))
 
 	return opt
 }
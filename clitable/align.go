@@ -0,0 +1,77 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Alignment - Horizontal alignment of the cells in a column.
+type Alignment int
+
+const (
+	Left Alignment = iota
+	Right
+	Center
+	// Auto - Right align numeric columns and left align the rest.
+	Auto
+)
+
+func (tp *TablePrinter) columnAlignment(column int, tableInfo *TableInfo) Alignment {
+	a := tp.alignment
+	if ca, ok := tp.columnAlignments[column]; ok {
+		a = ca
+	}
+	if a == Auto {
+		if column < len(tableInfo.NumericColumns) && tableInfo.NumericColumns[column] {
+			return Right
+		}
+		return Left
+	}
+	return a
+}
+
+// alignCell - Pads s to width with the given alignment.
+func alignCell(s string, width int, a Alignment) string {
+	l, _ := StringWidth(s)
+	padding := width - l
+	if padding <= 0 {
+		return s
+	}
+	switch a {
+	case Right:
+		return strings.Repeat(" ", padding) + s
+	case Center:
+		left := padding / 2
+		return strings.Repeat(" ", left) + s + strings.Repeat(" ", padding-left)
+	default:
+		return s + strings.Repeat(" ", padding)
+	}
+}
+
+// sgrRe - ANSI SGR escape sequences used to color text.
+var sgrRe = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// StripANSI - Removes the ANSI SGR escape sequences from s.
+func StripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return sgrRe.ReplaceAllString(s, "")
+}
+
+// numberRe - Decimal literals like 42, -3.14, .5 or 1e-3.
+var numberRe = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// isNumber - Whether the cell is a decimal number, ignoring colors and surrounding spaces.
+// Values like NaN or Inf are not considered numbers.
+func isNumber(s string) bool {
+	return numberRe.MatchString(strings.TrimSpace(StripANSI(s)))
+}
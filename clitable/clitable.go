@@ -24,7 +24,6 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 )
 
@@ -35,6 +34,18 @@ var Logger = log.New(ioutil.Discard, "clitable DEBUG ", log.LstdFlags)
 type TablePrinter struct {
 	tableConfig tableConfig
 	separator   rune
+
+	// Max table width, 0 uses the terminal width and a negative value disables the limit.
+	maxWidth        int
+	overflow        Overflow
+	columnMaxWidths map[int]int
+	columnOverflows map[int]Overflow
+
+	alignment        Alignment
+	columnAlignments map[int]Alignment
+
+	// Number of rows used to calculate the column widths when streaming, 0 disables streaming.
+	streamRows int
 }
 
 type tableConfig struct {
@@ -85,6 +96,10 @@ const (
 	Compact
 	Space
 	CSV
+	TSV
+	Markdown
+	HTML
+	JSON
 )
 
 func (tp *TablePrinter) HasHeader(b bool) *TablePrinter {
@@ -92,6 +107,53 @@ func (tp *TablePrinter) HasHeader(b bool) *TablePrinter {
 	return tp
 }
 
+// MaxWidth - Max width of the table including borders.
+// Wider tables have their widest columns shrunk to fit.
+// By default the table is limited to the terminal width when writing to a terminal, use a negative value to disable the limit.
+func (tp *TablePrinter) MaxWidth(n int) *TablePrinter {
+	tp.maxWidth = n
+	return tp
+}
+
+// ColumnMaxWidth - Max width of the content of the column at the given index.
+func (tp *TablePrinter) ColumnMaxWidth(column, n int) *TablePrinter {
+	if tp.columnMaxWidths == nil {
+		tp.columnMaxWidths = map[int]int{}
+	}
+	tp.columnMaxWidths[column] = n
+	return tp
+}
+
+// Overflow - How to fit cells wider than their column, Wrap by default.
+func (tp *TablePrinter) Overflow(o Overflow) *TablePrinter {
+	tp.overflow = o
+	return tp
+}
+
+// ColumnOverflow - How to fit cells wider than the column at the given index.
+func (tp *TablePrinter) ColumnOverflow(column int, o Overflow) *TablePrinter {
+	if tp.columnOverflows == nil {
+		tp.columnOverflows = map[int]Overflow{}
+	}
+	tp.columnOverflows[column] = o
+	return tp
+}
+
+// Align - Alignment for all columns, Left by default.
+func (tp *TablePrinter) Align(a Alignment) *TablePrinter {
+	tp.alignment = a
+	return tp
+}
+
+// ColumnAlign - Alignment for the column at the given index.
+func (tp *TablePrinter) ColumnAlign(column int, a Alignment) *TablePrinter {
+	if tp.columnAlignments == nil {
+		tp.columnAlignments = map[int]Alignment{}
+	}
+	tp.columnAlignments[column] = a
+	return tp
+}
+
 func (tp *TablePrinter) Separator(c rune) *TablePrinter {
 	tp.separator = c
 	return tp
@@ -199,25 +261,28 @@ func (tp *TablePrinter) Print(t Table) error {
 }
 
 func (tp *TablePrinter) Fprint(w io.Writer, t Table) error {
-	tableInfo, err := GetTableInfo(t)
+	if tp.streamRows > 0 {
+		return tp.fprintStream(w, t)
+	}
+	tableInfo, err := getTableInfo(t, tp.tableConfig.HasHeader)
 	if err != nil {
 		return err
 	}
-	if tp.tableConfig.Style == CSV {
-		return tp.fprintCSV(w, t, tableInfo)
-	}
-	return tp.fprint(w, t, tableInfo)
+	return tp.render(w, t, tableInfo)
 }
 
 func (tp *TablePrinter) FprintCSVReader(w io.Writer, r io.Reader) error {
-	// TODO: I am sure I can make this simpler and maybe even not necessary
+	if tp.streamRows > 0 {
+		return tp.fprintStream(w, CSVTable{Reader: r, Separator: tp.separator})
+	}
+	// The reader is read twice, once to get the table info and once to print it
 	var readerCopy bytes.Buffer
 	reader := io.TeeReader(r, &readerCopy)
 	t := CSVTable{
 		Reader:    reader,
 		Separator: tp.separator,
 	}
-	tableInfo, err := GetTableInfo(t)
+	tableInfo, err := getTableInfo(t, tp.tableConfig.HasHeader)
 	if err != nil {
 		return err
 	}
@@ -226,10 +291,24 @@ func (tp *TablePrinter) FprintCSVReader(w io.Writer, r io.Reader) error {
 		Reader:    &readerCopy,
 		Separator: tp.separator,
 	}
-	if tp.tableConfig.Style == CSV {
-		return tp.fprintCSV(os.Stdout, t, tableInfo)
+	return tp.render(w, t, tableInfo)
+}
+
+// render - Prints the table with the renderer for the style.
+func (tp *TablePrinter) render(w io.Writer, t Table, tableInfo *TableInfo) error {
+	switch tp.tableConfig.Style {
+	case CSV:
+		return tp.fprintCSV(w, t, tableInfo)
+	case TSV:
+		return tp.fprintTSV(w, t, tableInfo)
+	case Markdown:
+		return tp.fprintMarkdown(w, t, tableInfo)
+	case HTML:
+		return tp.fprintHTML(w, t, tableInfo)
+	case JSON:
+		return tp.fprintJSON(w, t, tableInfo)
 	}
-	return tp.fprint(os.Stdout, t, tableInfo)
+	return tp.fprint(w, t, tableInfo)
 }
 
 func (tp *TablePrinter) fprintCSV(w io.Writer, t Table, tableInfo *TableInfo) error {
@@ -237,112 +316,128 @@ func (tp *TablePrinter) fprintCSV(w io.Writer, t Table, tableInfo *TableInfo) er
 	defer csvw.Flush()
 
 	for row := range t.RowIterator() {
-		r := make([]string, tableInfo.Columns)
 		if row.Error != nil {
 			return row.Error
 		}
-		copy(r, row.Fields)
+		// Rows are padded to the number of columns, when streaming later rows might have more
+		r := row.Fields
+		if len(r) < tableInfo.Columns {
+			r = make([]string, tableInfo.Columns)
+			copy(r, row.Fields)
+		}
 		csvw.Write(r)
 	}
 	return nil
 }
 
 func (tp *TablePrinter) fprint(w io.Writer, t Table, tableInfo *TableInfo) error {
-	header := tp.tableConfig.TopLine
+	// Work on a copy so the caller's table info keeps the natural widths
+	fitInfo := *tableInfo
+	fitInfo.ColumnWidths = tp.fitColumnWidths(w, tableInfo)
+	tableInfo = &fitInfo
+
 	rowCounter := 0
 	for row := range t.RowIterator() {
 		if row.Error != nil {
 			return row.Error
 		}
-		if header {
-			printTopLine(tp.tableConfig, tableInfo)
-			header = false
+		tableInfo.growColumns(row.Fields)
+		// Cells are wrapped or truncated to the column width, wrapping can make the row taller
+		fields := make([]string, len(row.Fields))
+		rowHeight := 1
+		for j, field := range row.Fields {
+			o := tp.columnOverflow(j)
+			if o == Expand {
+				// Cells wider than what was sampled when streaming grow the column from this row on
+				for _, line := range strings.Split(field, "\n") {
+					if l, _ := StringWidth(line); l > tableInfo.ColumnWidths[j] {
+						tableInfo.ColumnWidths[j] = l
+					}
+				}
+			}
+			field = fitCell(field, tableInfo.ColumnWidths[j], o)
+			fields[j] = field
+			if h := strings.Count(field, "\n") + 1; h > rowHeight {
+				rowHeight = h
+			}
+		}
+		// Lines are printed before the row since the number of rows isn't known when streaming
+		if rowCounter == 0 {
+			if tp.tableConfig.TopLine {
+				printTopLine(w, tp.tableConfig, tableInfo)
+			}
+		} else if rowCounter == 1 && tp.tableConfig.HeaderDividerLine {
+			if tp.tableConfig.HasHeader {
+				printHeaderDividerLine(w, tp.tableConfig, tableInfo)
+			} else {
+				printDividerLine(w, tp.tableConfig, tableInfo)
+			}
+		} else if tp.tableConfig.DividerLine {
+			printDividerLine(w, tp.tableConfig, tableInfo)
 		}
-		for i := 0; i < tableInfo.RowHeights[rowCounter]; i++ {
+		for i := 0; i < rowHeight; i++ {
 			if tp.tableConfig.ColumnEdges {
-				fmt.Printf(tp.tableConfig.Column)
-			} else {
-				fmt.Printf("")
+				fmt.Fprint(w, tp.tableConfig.Column)
 			}
 			for j := 0; j < tableInfo.Columns; j++ {
 				if j > 0 {
-					fmt.Printf(tp.tableConfig.Column)
-				}
-				if len(row.Fields) <= j {
-					fmt.Printf(" %-"+strconv.Itoa(tableInfo.ColumnWidths[j])+"s ", " ")
-					continue
+					fmt.Fprint(w, tp.tableConfig.Column)
 				}
-				multiLine := strings.Split(row.Fields[j], "\n")
-				if len(multiLine) > i {
-					_, c := StringWidth(multiLine[i])
-					if c > 0 {
-						fmt.Printf(" %-"+strconv.Itoa(tableInfo.ColumnWidths[j]-c)+"s ", multiLine[i])
-					} else {
-						fmt.Printf(" %-"+strconv.Itoa(tableInfo.ColumnWidths[j])+"s ", multiLine[i])
+				line := ""
+				if len(fields) > j {
+					multiLine := strings.Split(fields[j], "\n")
+					if len(multiLine) > i {
+						line = multiLine[i]
 					}
-				} else {
-					fmt.Printf(" %-"+strconv.Itoa(tableInfo.ColumnWidths[j])+"s ", " ")
 				}
+				fmt.Fprintf(w, " %s ", alignCell(line, tableInfo.ColumnWidths[j], tp.columnAlignment(j, tableInfo)))
 			}
 			if tp.tableConfig.ColumnEdges {
-				fmt.Println(tp.tableConfig.Column)
+				fmt.Fprintln(w, tp.tableConfig.Column)
 			} else {
-				fmt.Println("")
-			}
-		}
-		if rowCounter+1 < tableInfo.Rows {
-			if rowCounter+1 == 1 && tp.tableConfig.HeaderDividerLine {
-				if tp.tableConfig.HasHeader {
-					printHeaderDividerLine(tp.tableConfig, tableInfo)
-				} else {
-					printDividerLine(tp.tableConfig, tableInfo)
-				}
-			} else if tp.tableConfig.DividerLine {
-				printDividerLine(tp.tableConfig, tableInfo)
+				fmt.Fprintln(w, "")
 			}
 		}
 		rowCounter++
 	}
 	if tp.tableConfig.BottomLine {
-		printBottomLine(tp.tableConfig, tableInfo)
+		printBottomLine(w, tp.tableConfig, tableInfo)
 	}
 	return nil
 }
 
-func printTopLine(tableConfig tableConfig, tableInfo *TableInfo) {
-	printHorizontalLine(tableConfig.TopStart, tableConfig.TopJuncture, tableConfig.TopEnd, tableConfig.TopBody, tableConfig.ColumnEdges, tableInfo)
+func printTopLine(w io.Writer, tableConfig tableConfig, tableInfo *TableInfo) {
+	printHorizontalLine(w, tableConfig.TopStart, tableConfig.TopJuncture, tableConfig.TopEnd, tableConfig.TopBody, tableConfig.ColumnEdges, tableInfo)
 }
 
-func printBottomLine(tableConfig tableConfig, tableInfo *TableInfo) {
-	printHorizontalLine(tableConfig.BottomStart, tableConfig.BottomJuncture, tableConfig.BottomEnd, tableConfig.BottomBody, tableConfig.ColumnEdges, tableInfo)
+func printBottomLine(w io.Writer, tableConfig tableConfig, tableInfo *TableInfo) {
+	printHorizontalLine(w, tableConfig.BottomStart, tableConfig.BottomJuncture, tableConfig.BottomEnd, tableConfig.BottomBody, tableConfig.ColumnEdges, tableInfo)
 }
 
-func printDividerLine(tableConfig tableConfig, tableInfo *TableInfo) {
-	printHorizontalLine(tableConfig.DividerStart, tableConfig.DividerJuncture, tableConfig.DividerEnd, tableConfig.DividerBody, tableConfig.ColumnEdges, tableInfo)
+func printDividerLine(w io.Writer, tableConfig tableConfig, tableInfo *TableInfo) {
+	printHorizontalLine(w, tableConfig.DividerStart, tableConfig.DividerJuncture, tableConfig.DividerEnd, tableConfig.DividerBody, tableConfig.ColumnEdges, tableInfo)
 }
 
-func printHeaderDividerLine(tableConfig tableConfig, tableInfo *TableInfo) {
-	printHorizontalLine(tableConfig.HeaderDividerStart, tableConfig.HeaderDividerJuncture, tableConfig.HeaderDividerEnd, tableConfig.HeaderDividerBody, tableConfig.ColumnEdges, tableInfo)
+func printHeaderDividerLine(w io.Writer, tableConfig tableConfig, tableInfo *TableInfo) {
+	printHorizontalLine(w, tableConfig.HeaderDividerStart, tableConfig.HeaderDividerJuncture, tableConfig.HeaderDividerEnd, tableConfig.HeaderDividerBody, tableConfig.ColumnEdges, tableInfo)
 }
 
-func printHorizontalLine(start, juncture, end, body string, columnEdges bool, tableInfo *TableInfo) {
+func printHorizontalLine(w io.Writer, start, juncture, end, body string, columnEdges bool, tableInfo *TableInfo) {
 	for i := 0; i < tableInfo.Columns; i++ {
 		if i == 0 {
 			if columnEdges {
-				fmt.Printf(start)
-			} else {
-				fmt.Printf("")
+				fmt.Fprint(w, start)
 			}
 		} else {
-			fmt.Printf(juncture)
+			fmt.Fprint(w, juncture)
 		}
 		// Column width with space padding on each side
-		fmt.Printf(strings.Repeat(body, tableInfo.ColumnWidths[i]+2))
+		fmt.Fprint(w, strings.Repeat(body, tableInfo.ColumnWidths[i]+2))
 		if i+1 == tableInfo.Columns {
 			if columnEdges {
-				fmt.Println(end)
+				fmt.Fprintln(w, end)
 			} else {
-				fmt.Println("")
+				fmt.Fprintln(w, "")
 			}
 		}
 	}
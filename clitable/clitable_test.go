@@ -9,6 +9,7 @@
 package clitable_test
 
 import (
+	"bytes"
 	"os"
 	"strconv"
 	"strings"
@@ -79,3 +80,192 @@ func TestStringWidth(t *testing.T) {
 		})
 	}
 }
+
+func TestTablePrinterWidth(t *testing.T) {
+	data := [][]string{
+		{"ID", "Description"},
+		{"1", "The quick brown fox jumps over the lazy dog"},
+		{"2", "short"},
+	}
+	tests := []struct {
+		name     string
+		tp       *clitable.TablePrinter
+		expected string
+	}{
+		{"no limit", clitable.NewTablePrinter().MaxWidth(-1), `┌────┬─────────────────────────────────────────────┐
+│ ID │ Description                                 │
+╞════╪═════════════════════════════════════════════╡
+│ 1  │ The quick brown fox jumps over the lazy dog │
+├────┼─────────────────────────────────────────────┤
+│ 2  │ short                                       │
+└────┴─────────────────────────────────────────────┘
+`},
+		{"max width wrap", clitable.NewTablePrinter().MaxWidth(24), `┌────┬─────────────────┐
+│ ID │ Description     │
+╞════╪═════════════════╡
+│ 1  │ The quick brown │
+│    │ fox jumps over  │
+│    │ the lazy dog    │
+├────┼─────────────────┤
+│ 2  │ short           │
+└────┴─────────────────┘
+`},
+		{"max width truncate", clitable.NewTablePrinter().MaxWidth(24).Overflow(clitable.Truncate), `┌────┬─────────────────┐
+│ ID │ Description     │
+╞════╪═════════════════╡
+│ 1  │ The quick brow… │
+├────┼─────────────────┤
+│ 2  │ short           │
+└────┴─────────────────┘
+`},
+		{"column max width", clitable.NewTablePrinter().ColumnMaxWidth(1, 10).ColumnOverflow(1, clitable.Truncate).SetStyle(clitable.Space), ` ID   Descripti… 
+ 1    The quick… 
+ 2    short      
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := tt.tp.Fprint(buf, clitable.SimpleTable{Data: data})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestTablePrinterWidthDoubleWidth(t *testing.T) {
+	data := [][]string{{"⚽⛪⚽⛪ Hello World"}}
+	buf := &bytes.Buffer{}
+	err := clitable.NewTablePrinter().MaxWidth(12).Fprint(buf, clitable.SimpleTable{Data: data})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `┌──────────┐
+│ ⚽⛪⚽⛪ │
+│ Hello    │
+│ World    │
+└──────────┘
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestTablePrinterAlign(t *testing.T) {
+	data := [][]string{
+		{"Name", "Count", "Note"},
+		{"a", "1", "x"},
+		{"b", "-20.5", "yyy"},
+	}
+	tests := []struct {
+		name     string
+		tp       *clitable.TablePrinter
+		expected string
+	}{
+		{"auto", clitable.NewTablePrinter().SetStyle(clitable.Ascii).Align(clitable.Auto), `+------+-------+------+
+| Name | Count | Note |
++======+=======+======+
+| a    |     1 | x    |
++------+-------+------+
+| b    | -20.5 | yyy  |
++------+-------+------+
+`},
+		{"column", clitable.NewTablePrinter().SetStyle(clitable.Ascii).ColumnAlign(0, clitable.Right).ColumnAlign(2, clitable.Center), `+------+-------+------+
+| Name | Count | Note |
++======+=======+======+
+|    a | 1     |  x   |
++------+-------+------+
+|    b | -20.5 | yyy  |
++------+-------+------+
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := tt.tp.Fprint(buf, clitable.SimpleTable{Data: data})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestTablePrinterAlignNumbers(t *testing.T) {
+	// Without a header the first row is also checked and NaN/Inf are not numbers
+	data := [][]string{
+		{"10", "NaN"},
+		{"200", "Inf"},
+	}
+	buf := &bytes.Buffer{}
+	err := clitable.NewTablePrinter().SetStyle(clitable.Ascii).HasHeader(false).Align(clitable.Auto).Fprint(buf, clitable.SimpleTable{Data: data})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `+-----+-----+
+|  10 | NaN |
++-----+-----+
+| 200 | Inf |
++-----+-----+
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// With a header the first row is skipped
+	buf.Reset()
+	err = clitable.NewTablePrinter().SetStyle(clitable.Ascii).Align(clitable.Auto).Fprint(buf, clitable.SimpleTable{Data: [][]string{{"Count"}, {"5"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected = `+-------+
+| Count |
++=======+
+|     5 |
++-------+
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestTablePrinterANSI(t *testing.T) {
+	red := "\x1b[31m"
+	reset := "\x1b[0m"
+	data := [][]string{
+		{"Status", "Count"},
+		{red + "failed" + reset, red + "10" + reset},
+		{"ok", "2"},
+	}
+	buf := &bytes.Buffer{}
+	err := clitable.NewTablePrinter().SetStyle(clitable.Space).Align(clitable.Auto).Fprint(buf, clitable.SimpleTable{Data: data})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := " Status   Count \n " + red + "failed" + reset + "      " + red + "10" + reset + " \n ok           2 \n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, buf.String())
+	}
+
+	buf.Reset()
+	err = clitable.NewTablePrinter().SetStyle(clitable.Space).MaxWidth(8).Fprint(buf, clitable.SimpleTable{Data: [][]string{{red + "aaa bbb" + reset}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// Colors are reset at the end of each wrapped line
+	expected = " " + red + "aaa" + reset + "    \n " + red + "bbb" + reset + "    \n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, buf.String())
+	}
+
+	l, c := clitable.StringWidth(red + "⚽ok" + reset)
+	if l != 4 || c != 1 {
+		t.Errorf("expected width 4 with 1 double width char, got: %d, %d", l, c)
+	}
+}
@@ -0,0 +1,532 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/DavidGamba/dgtools/clitable"
+	"github.com/nsf/termbox-go"
+)
+
+// Max width of a column in the pager, longer cells are truncated.
+const maxColumnWidth = 40
+
+// pager - Interactive table viewer state.
+type pager struct {
+	header []string
+	rows   [][]string
+	// Width of each column in the original order
+	widths  []int
+	numeric []bool
+	// Visible columns in display order, as indexes into header
+	order []int
+
+	// Cursor position, row index into rows and column index into order
+	row, col int
+	// First visible row and first visible column index into order
+	top, left int
+
+	query     string
+	searching bool
+	status    string
+}
+
+// newPager - Builds the pager from the table, the first row is used as the header.
+// Tables without a header get numbered columns.
+func newPager(t clitable.Table, hasHeader bool) (*pager, error) {
+	data := [][]string{}
+	for row := range t.RowIterator() {
+		if row.Error != nil {
+			return nil, row.Error
+		}
+		fields := make([]string, len(row.Fields))
+		for i, f := range row.Fields {
+			fields[i] = clitable.StripANSI(f)
+		}
+		data = append(data, fields)
+	}
+	info, err := clitable.GetTableInfo(clitable.SimpleTable{Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pager{}
+	if hasHeader && len(data) > 0 {
+		p.header = data[0]
+		p.rows = data[1:]
+	} else {
+		p.rows = data
+	}
+	for len(p.header) < info.Columns {
+		p.header = append(p.header, strconv.Itoa(len(p.header)+1))
+	}
+	for i := range p.header {
+		width := 0
+		if i < len(info.ColumnWidths) {
+			width = info.ColumnWidths[i]
+		}
+		if l, _ := clitable.StringWidth(p.header[i]); l > width {
+			width = l
+		}
+		if width > maxColumnWidth {
+			width = maxColumnWidth
+		}
+		p.widths = append(p.widths, width)
+		p.numeric = append(p.numeric, i < len(info.NumericColumns) && info.NumericColumns[i])
+		p.order = append(p.order, i)
+	}
+	return p, nil
+}
+
+func (p *pager) cell(row []string, column int) string {
+	if column < len(row) {
+		return row[column]
+	}
+	return ""
+}
+
+func (p *pager) moveRow(n int) {
+	p.row += n
+	if p.row >= len(p.rows) {
+		p.row = len(p.rows) - 1
+	}
+	if p.row < 0 {
+		p.row = 0
+	}
+}
+
+func (p *pager) moveCol(n int) {
+	p.col += n
+	if p.col >= len(p.order) {
+		p.col = len(p.order) - 1
+	}
+	if p.col < 0 {
+		p.col = 0
+	}
+}
+
+// hideColumn - Hides the current column, the last visible column can't be hidden.
+func (p *pager) hideColumn() {
+	if len(p.order) <= 1 {
+		p.status = "can't hide the last column"
+		return
+	}
+	p.status = fmt.Sprintf("hid column '%s'", p.header[p.order[p.col]])
+	p.order = append(p.order[:p.col:p.col], p.order[p.col+1:]...)
+	p.moveCol(0)
+}
+
+// showColumns - Shows all the columns in their original order.
+func (p *pager) showColumns() {
+	p.order = p.order[:0]
+	for i := range p.header {
+		p.order = append(p.order, i)
+	}
+	p.moveCol(0)
+	p.status = "showing all columns"
+}
+
+// moveColumn - Moves the current column n positions keeping the cursor on it.
+func (p *pager) moveColumn(n int) {
+	to := p.col + n
+	if to < 0 || to >= len(p.order) {
+		return
+	}
+	p.order[p.col], p.order[to] = p.order[to], p.order[p.col]
+	p.col = to
+}
+
+// matches - Returns the rune ranges in s that match the query, case insensitive.
+func matches(s, query string) [][2]int {
+	ranges := [][2]int{}
+	if query == "" {
+		return ranges
+	}
+	sr := []rune(strings.ToLower(s))
+	qr := []rune(strings.ToLower(query))
+	for i := 0; i+len(qr) <= len(sr); i++ {
+		if string(sr[i:i+len(qr)]) == string(qr) {
+			ranges = append(ranges, [2]int{i, i + len(qr)})
+			i += len(qr) - 1
+		}
+	}
+	return ranges
+}
+
+// rowMatches - Whether any visible cell in the row matches the query.
+func (p *pager) rowMatches(row []string) bool {
+	for _, c := range p.order {
+		if len(matches(p.cell(row, c), p.query)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// search - Moves to the next row matching the query starting at from, wrapping around.
+func (p *pager) search(from int, forward bool) bool {
+	n := len(p.rows)
+	for i := 0; i < n; i++ {
+		r := ((from+i)%n + n) % n
+		if !forward {
+			r = ((from-i)%n + n) % n
+		}
+		if p.rowMatches(p.rows[r]) {
+			p.row = r
+			// Move the cursor to the first matching column
+			for j, c := range p.order {
+				if len(matches(p.cell(p.rows[r], c), p.query)) > 0 {
+					p.col = j
+					break
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// visibleRow - Returns the header and the cells of the current row for the visible columns.
+func (p *pager) visibleRow() ([]string, []string) {
+	header := []string{}
+	fields := []string{}
+	for _, c := range p.order {
+		header = append(header, p.header[c])
+		if len(p.rows) > 0 {
+			fields = append(fields, p.cell(p.rows[p.row], c))
+		}
+	}
+	return header, fields
+}
+
+// rowCSV - Returns the visible columns of the current row as a CSV line.
+func (p *pager) rowCSV() string {
+	_, fields := p.visibleRow()
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(fields)
+	w.Flush()
+	return buf.String()
+}
+
+// rowJSON - Returns the visible columns of the current row as a JSON object keyed by the header in column order.
+func (p *pager) rowJSON() string {
+	header, fields := p.visibleRow()
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i := range fields {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		k, _ := json.Marshal(header[i])
+		v, _ := json.Marshal(fields[i])
+		fmt.Fprintf(&buf, "%s: %s", k, v)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// scroll - Updates the first visible row and column so the cursor is on screen.
+func (p *pager) scroll(width, height int) {
+	rows := height - 3
+	if rows < 1 {
+		rows = 1
+	}
+	if p.row < p.top {
+		p.top = p.row
+	}
+	if p.row >= p.top+rows {
+		p.top = p.row - rows + 1
+	}
+	if p.col < p.left {
+		p.left = p.col
+	}
+	for p.left < p.col && !p.fits(p.left, p.col, width) {
+		p.left++
+	}
+}
+
+// fits - Whether the columns from left to col fit in the screen width.
+func (p *pager) fits(left, col, width int) bool {
+	used := 0
+	for i := left; i <= col; i++ {
+		used += p.widths[p.order[i]] + 3
+	}
+	return used <= width
+}
+
+// copyToClipboard - Copies the text using the first available clipboard tool.
+// Falls back to the OSC 52 escape sequence supported by most terminals, also over ssh.
+func copyToClipboard(text string) error {
+	tools := [][]string{
+		{"pbcopy"},
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		err := cmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to run %s: %w", tool[0], err)
+		}
+		return nil
+	}
+	_, err := fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+func (p *pager) copyRow(format string) {
+	if len(p.rows) == 0 {
+		return
+	}
+	text := p.rowCSV()
+	if format == "JSON" {
+		text = p.rowJSON()
+	}
+	err := copyToClipboard(text)
+	if err != nil {
+		p.status = fmt.Sprintf("ERROR: %s", err)
+		return
+	}
+	p.status = fmt.Sprintf("copied row %d as %s", p.row+1, format)
+}
+
+const pagerHelp = "q: quit, /: search, n/N: next/prev match, x: hide column, a: show all, </>: move column, c: copy CSV, J: copy JSON"
+
+// Run - Runs the pager until the user quits.
+func (p *pager) Run() error {
+	err := termbox.Init()
+	if err != nil {
+		return fmt.Errorf("failed to initialize terminal: %w", err)
+	}
+	defer termbox.Close()
+	termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+
+	p.status = pagerHelp
+	for {
+		width, height := termbox.Size()
+		p.scroll(width, height)
+		p.draw(width, height)
+
+		ev := termbox.PollEvent()
+		switch ev.Type {
+		case termbox.EventError:
+			return ev.Err
+		case termbox.EventMouse:
+			switch ev.Key {
+			case termbox.MouseWheelUp:
+				p.moveRow(-3)
+			case termbox.MouseWheelDown:
+				p.moveRow(3)
+			}
+			continue
+		case termbox.EventKey:
+		default:
+			continue
+		}
+
+		if p.searching {
+			p.handleSearchKey(ev)
+			continue
+		}
+
+		page := height - 3
+		switch ev.Key {
+		case termbox.KeyEsc, termbox.KeyCtrlC:
+			return nil
+		case termbox.KeyArrowUp:
+			p.moveRow(-1)
+		case termbox.KeyArrowDown, termbox.KeyEnter:
+			p.moveRow(1)
+		case termbox.KeyArrowLeft:
+			p.moveCol(-1)
+		case termbox.KeyArrowRight:
+			p.moveCol(1)
+		case termbox.KeyPgup, termbox.KeyCtrlU:
+			p.moveRow(-page)
+		case termbox.KeyPgdn, termbox.KeyCtrlD, termbox.KeySpace:
+			p.moveRow(page)
+		case termbox.KeyHome:
+			p.col = 0
+		case termbox.KeyEnd:
+			p.moveCol(len(p.order))
+		default:
+			switch ev.Ch {
+			case 'q':
+				return nil
+			case 'k':
+				p.moveRow(-1)
+			case 'j':
+				p.moveRow(1)
+			case 'h':
+				p.moveCol(-1)
+			case 'l':
+				p.moveCol(1)
+			case 'g':
+				p.row = 0
+			case 'G':
+				p.moveRow(len(p.rows))
+			case '0', '^':
+				p.col = 0
+			case '$':
+				p.moveCol(len(p.order))
+			case '/':
+				p.searching = true
+				p.query = ""
+			case 'n':
+				if p.query != "" && !p.search(p.row+1, true) {
+					p.status = fmt.Sprintf("pattern not found: %s", p.query)
+				}
+			case 'N':
+				if p.query != "" && !p.search(p.row-1, false) {
+					p.status = fmt.Sprintf("pattern not found: %s", p.query)
+				}
+			case 'x':
+				p.hideColumn()
+			case 'a':
+				p.showColumns()
+			case '<':
+				p.moveColumn(-1)
+			case '>':
+				p.moveColumn(1)
+			case 'c':
+				p.copyRow("CSV")
+			case 'J':
+				p.copyRow("JSON")
+			case '?':
+				p.status = pagerHelp
+			}
+		}
+	}
+}
+
+// handleSearchKey - Updates the search query, moving to the first match as the query is typed.
+func (p *pager) handleSearchKey(ev termbox.Event) {
+	start := p.row
+	switch ev.Key {
+	case termbox.KeyEsc, termbox.KeyCtrlC:
+		p.searching = false
+		p.query = ""
+		p.status = ""
+		return
+	case termbox.KeyEnter:
+		p.searching = false
+		return
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		if r := []rune(p.query); len(r) > 0 {
+			p.query = string(r[:len(r)-1])
+		}
+	case termbox.KeySpace:
+		p.query += " "
+	default:
+		if ev.Ch == 0 {
+			return
+		}
+		p.query += string(ev.Ch)
+	}
+	if p.query != "" && !p.search(start, true) {
+		p.status = fmt.Sprintf("pattern not found: %s", p.query)
+	} else {
+		p.status = ""
+	}
+}
+
+// printCell - Prints s at x,y padded to width, highlighting the query matches.
+func (p *pager) printCell(x, y, width int, s string, fg, bg termbox.Attribute, rightAlign bool) int {
+	s = clitable.TruncateString(s, width)
+	l, _ := clitable.StringWidth(s)
+	if rightAlign && l < width {
+		x += width - l
+	}
+	highlight := map[int]bool{}
+	for _, m := range matches(s, p.query) {
+		for i := m[0]; i < m[1]; i++ {
+			highlight[i] = true
+		}
+	}
+	for i, r := range []rune(s) {
+		cfg, cbg := fg, bg
+		if highlight[i] {
+			cfg, cbg = termbox.ColorBlack, termbox.ColorYellow
+		}
+		termbox.SetCell(x, y, r, cfg, cbg)
+		rw, _ := clitable.StringWidth(string(r))
+		x += rw
+	}
+	return x
+}
+
+func (p *pager) draw(width, height int) {
+	const colorDefault = termbox.ColorDefault
+	termbox.Clear(colorDefault, colorDefault)
+
+	drawRow := func(y int, fields []string, current bool, header bool) {
+		x := 0
+		for i := p.left; i < len(p.order) && x < width; i++ {
+			c := p.order[i]
+			fg, bg := colorDefault, colorDefault
+			if header {
+				fg |= termbox.AttrBold
+			}
+			if current {
+				fg |= termbox.AttrReverse
+			}
+			if (header || current) && i == p.col {
+				fg |= termbox.AttrBold | termbox.AttrUnderline
+			}
+			// Fill the cell padding so the current row is highlighted across the whole cell
+			for cx := x; cx < x+p.widths[c]+2; cx++ {
+				termbox.SetCell(cx, y, ' ', fg, bg)
+			}
+			p.printCell(x+1, y, p.widths[c], p.cell(fields, c), fg, bg, p.numeric[c] && !header)
+			x += p.widths[c] + 2
+			if x < width {
+				termbox.SetCell(x, y, '│', colorDefault, colorDefault)
+			}
+			x++
+		}
+	}
+
+	drawRow(0, p.header, false, true)
+	for x := 0; x < width; x++ {
+		termbox.SetCell(x, 1, '═', colorDefault, colorDefault)
+	}
+	for i := 0; i < height-3 && p.top+i < len(p.rows); i++ {
+		r := p.top + i
+		drawRow(i+2, p.rows[r], r == p.row, false)
+	}
+
+	status := p.status
+	if p.searching {
+		status = "/" + p.query
+		termbox.SetCursor(len([]rune(status)), height-1)
+	} else {
+		termbox.HideCursor()
+	}
+	position := fmt.Sprintf(" row %d/%d, col %d/%d ", p.row+1, len(p.rows), p.col+1, len(p.order))
+	p.printCell(0, height-1, width, status, termbox.ColorBlue, colorDefault, false)
+	if l := len([]rune(position)); l < width {
+		p.printCell(width-l, height-1, l, position, termbox.ColorBlack, termbox.ColorWhite, false)
+	}
+	termbox.Flush()
+}
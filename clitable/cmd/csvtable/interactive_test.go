@@ -0,0 +1,137 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DavidGamba/dgtools/clitable"
+)
+
+func testPager(t *testing.T, hasHeader bool) *pager {
+	t.Helper()
+	data := `name,count,region
+web-1,10,us-east
+db-1,2,eu-west
+"web, 2",300,us-west
+`
+	p, err := newPager(clitable.CSVTable{Reader: strings.NewReader(data)}, hasHeader)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return p
+}
+
+func TestNewPager(t *testing.T) {
+	p := testPager(t, true)
+	if !reflect.DeepEqual(p.header, []string{"name", "count", "region"}) {
+		t.Errorf("unexpected header: %v", p.header)
+	}
+	if len(p.rows) != 3 {
+		t.Errorf("unexpected rows: %v", p.rows)
+	}
+	if !reflect.DeepEqual(p.widths, []int{6, 5, 7}) {
+		t.Errorf("unexpected widths: %v", p.widths)
+	}
+	if !reflect.DeepEqual(p.numeric, []bool{false, true, false}) {
+		t.Errorf("unexpected numeric: %v", p.numeric)
+	}
+
+	p = testPager(t, false)
+	if !reflect.DeepEqual(p.header, []string{"1", "2", "3"}) {
+		t.Errorf("unexpected header: %v", p.header)
+	}
+	if len(p.rows) != 4 {
+		t.Errorf("unexpected rows: %v", p.rows)
+	}
+}
+
+func TestPagerColumns(t *testing.T) {
+	p := testPager(t, true)
+	p.moveCol(1)
+	p.moveColumn(1)
+	if !reflect.DeepEqual(p.order, []int{0, 2, 1}) || p.col != 2 {
+		t.Errorf("unexpected order: %v, col %d", p.order, p.col)
+	}
+	p.hideColumn()
+	if !reflect.DeepEqual(p.order, []int{0, 2}) || p.col != 1 {
+		t.Errorf("unexpected order: %v, col %d", p.order, p.col)
+	}
+	p.hideColumn()
+	p.hideColumn()
+	if !reflect.DeepEqual(p.order, []int{0}) || !strings.Contains(p.status, "last column") {
+		t.Errorf("unexpected order: %v", p.order)
+	}
+	p.showColumns()
+	if !reflect.DeepEqual(p.order, []int{0, 1, 2}) {
+		t.Errorf("unexpected order: %v", p.order)
+	}
+}
+
+func TestPagerSearch(t *testing.T) {
+	p := testPager(t, true)
+	p.query = "WEB"
+	if !p.search(p.row+1, true) || p.row != 2 || p.col != 0 {
+		t.Errorf("unexpected position: %d, %d", p.row, p.col)
+	}
+	if !p.search(p.row+1, true) || p.row != 0 {
+		t.Errorf("search didn't wrap around: %d", p.row)
+	}
+	if !p.search(p.row-1, false) || p.row != 2 {
+		t.Errorf("unexpected position: %d", p.row)
+	}
+	p.query = "west"
+	if !p.search(0, true) || p.row != 1 || p.col != 2 {
+		t.Errorf("unexpected position: %d, %d", p.row, p.col)
+	}
+	p.query = "missing"
+	if p.search(0, true) {
+		t.Errorf("unexpected match")
+	}
+
+	if got := matches("Web-web", "web"); !reflect.DeepEqual(got, [][2]int{{0, 3}, {4, 7}}) {
+		t.Errorf("unexpected matches: %v", got)
+	}
+}
+
+func TestPagerCopy(t *testing.T) {
+	p := testPager(t, true)
+	p.row = 2
+	p.moveCol(1)
+	p.moveColumn(-1)
+	if got := p.rowCSV(); got != "300,\"web, 2\",us-west\n" {
+		t.Errorf("unexpected CSV: %q", got)
+	}
+	p.hideColumn()
+	if got := p.rowJSON(); got != `{"name": "web, 2", "region": "us-west"}`+"\n" {
+		t.Errorf("unexpected JSON: %q", got)
+	}
+}
+
+func TestPagerScroll(t *testing.T) {
+	p := testPager(t, true)
+	p.row = 2
+	p.scroll(80, 4)
+	if p.top != 2 {
+		t.Errorf("unexpected top: %d", p.top)
+	}
+	p.col = 2
+	// name and count fit in 17 columns, region needs 10 more
+	p.scroll(20, 4)
+	if p.left != 1 {
+		t.Errorf("unexpected left: %d", p.left)
+	}
+	p.col = 0
+	p.scroll(20, 4)
+	if p.left != 0 {
+		t.Errorf("unexpected left: %d", p.left)
+	}
+}
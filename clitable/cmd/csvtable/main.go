@@ -0,0 +1,290 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/*
+Package csvtable provides a tool to view csv files on the cmdline.
+
+	┌──┬──┐
+	│  │  │
+	├──┼──┤
+	└──┴──┘
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/DavidGamba/dgtools/clitable"
+	"github.com/DavidGamba/go-getoptions"
+)
+
+const semVersion = "0.4.0."
+
+var Logger = log.New(io.Discard, "", log.LstdFlags)
+
+func main() {
+	os.Exit(program(os.Args))
+}
+
+func examples() {
+	fmt.Fprintf(os.Stderr, `EXAMPLES:
+    # Read CSV file
+    csvtable <csv_filename>
+
+    # Pipe CSV file to csvtable
+    cat <csv_filename> | csvtable
+
+    # Read TSV file
+    csvtable <tsv_filename> --tsv
+
+    # Print as a Markdown table
+    csvtable <csv_filename> --style markdown
+
+    # Print a large file without reading it all first
+    csvtable <csv_filename> --stream 1000
+
+    # Query the data
+    csvtable <csv_filename> --cols name,region --where status=failed --sort=-duration --limit 20
+
+    # Browse the data with a frozen header, press ? for the key bindings
+    csvtable <csv_filename> --interactive
+
+    # Summarize the data and write it back as CSV
+    csvtable <csv_filename> --group-by region --count --sum duration --style csv
+`)
+}
+
+func program(args []string) int {
+	opt := getoptions.New()
+	opt.Bool("debug", false, opt.Description("Print debug output"))
+	opt.Bool("version", false, opt.Alias("V"))
+	opt.Bool("tsv", false, opt.Description("Read TSV file"))
+	opt.Bool("no-header", true, opt.Description("Data has no header"))
+	opt.Bool("normalize", false, opt.Description("normalize csv data, same as --style csv"))
+	opt.String("style", "full", opt.ValidValues(clitable.StyleNames...), opt.Description("Output style"))
+	opt.Int("max-width", 0, opt.Description("Max table width, defaults to the terminal width, use -1 for no limit"))
+	opt.Bool("truncate", false, opt.Description("Truncate cells wider than their column instead of wrapping them"))
+	opt.Bool("expand", false, opt.Description("Grow columns for wider cells instead of wrapping them"))
+	opt.Int("stream", 0, opt.ArgName("rows"), opt.Description("Print rows as they are read, sizing the columns from the first <rows> rows"))
+	opt.String("align", "left", opt.ValidValues("left", "right", "center", "auto"), opt.Description("Column alignment, auto right aligns numeric columns"))
+	opt.StringSlice("cols", 1, 1, opt.Description("Comma separated list of columns to print"))
+	opt.StringSlice("where", 1, 1, opt.Description("Filter rows with a condition like status=failed or duration>=10, can be repeated.\nOperators: = != < <= > >= ~ (regex) !~"))
+	opt.StringSlice("sort", 1, 1, opt.Description("Comma separated list of columns to sort by, prefix with - for descending order, like --sort=-count"))
+	opt.Int("limit", 0, opt.Description("Max number of rows to print"))
+	opt.StringSlice("group-by", 1, 1, opt.Description("Comma separated list of columns to group by"))
+	opt.Bool("count", false, opt.Description("Add a count column with the number of rows per group"))
+	opt.StringSlice("sum", 1, 1, opt.Description("Comma separated list of columns to sum per group"))
+	opt.Bool("interactive", false, opt.Alias("i"), opt.Description("Browse the table in an interactive pager"))
+	opt.HelpSynopsisArg("<filename>", "CSV|TSV file to read")
+
+	opt.SetCommandFn(Run)
+	opt.HelpCommand("help", opt.Alias("?"))
+	remaining, err := opt.Parse(args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		return 1
+	}
+	if opt.Called("version") {
+		v, err := version(semVersion)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Version: %s\n", v)
+		os.Exit(0)
+	}
+	if opt.Called("debug") {
+		Logger.SetOutput(os.Stderr)
+		clitable.Logger.SetOutput(os.Stderr)
+	}
+	Logger.Println(remaining)
+
+	ctx, cancel, done := getoptions.InterruptContext()
+	defer func() { cancel(); <-done }()
+
+	err = opt.Dispatch(ctx, remaining)
+	if err != nil {
+		if errors.Is(err, getoptions.ErrorHelpCalled) {
+			examples()
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func Run(ctx context.Context, opt *getoptions.GetOpt, args []string) error {
+	header := opt.Value("no-header").(bool)
+	normalize := opt.Value("normalize").(bool)
+	styleName := opt.Value("style").(string)
+	maxWidth := opt.Value("max-width").(int)
+	truncate := opt.Value("truncate").(bool)
+	expand := opt.Value("expand").(bool)
+	stream := opt.Value("stream").(int)
+	align := opt.Value("align").(string)
+	interactive := opt.Value("interactive").(bool)
+
+	var reader io.Reader
+	if len(args) < 1 {
+		// Check if stdin is pipe p or device D
+		statStdin, _ := os.Stdin.Stat()
+		stdinIsDevice := (statStdin.Mode() & os.ModeDevice) != 0
+
+		if stdinIsDevice {
+			fmt.Fprint(os.Stderr, opt.Help())
+			examples()
+			os.Exit(1)
+		}
+		Logger.Printf("Reading from stdin\n")
+		reader = os.Stdin
+	} else {
+		filename := args[0]
+		Logger.Printf("Reading from file %s\n", filename)
+		fh, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		reader = fh
+	}
+	tp := clitable.NewTablePrinter()
+	if opt.Called("tsv") {
+		tp.Separator('\t')
+	}
+	style, err := clitable.ParseStyle(styleName)
+	if err != nil {
+		return err
+	}
+	tp.SetStyle(style)
+	if normalize {
+		tp.SetStyle(clitable.CSV)
+	}
+	tp.MaxWidth(maxWidth)
+	if truncate {
+		tp.Overflow(clitable.Truncate)
+	}
+	if expand {
+		tp.Overflow(clitable.Expand)
+	}
+	tp.Stream(stream)
+	switch align {
+	case "right":
+		tp.Align(clitable.Right)
+	case "center":
+		tp.Align(clitable.Center)
+	case "auto":
+		tp.Align(clitable.Auto)
+	}
+
+	q, err := buildQuery(opt)
+	if err != nil {
+		return err
+	}
+	if q == nil && !interactive {
+		return tp.HasHeader(header).FprintCSVReader(os.Stdout, reader)
+	}
+	if q != nil && !header {
+		return fmt.Errorf("query options require data with a header")
+	}
+	separator := ','
+	if opt.Called("tsv") {
+		separator = '\t'
+	}
+	var t clitable.Table = clitable.CSVTable{Reader: reader, Separator: separator}
+	if q != nil {
+		t, err = q.Apply(t)
+		if err != nil {
+			return err
+		}
+	}
+	if interactive {
+		p, err := newPager(t, header)
+		if err != nil {
+			return err
+		}
+		return p.Run()
+	}
+	return tp.HasHeader(header).Fprint(os.Stdout, t)
+}
+
+// splitList - Splits the comma separated values.
+func splitList(values []string) []string {
+	list := []string{}
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				list = append(list, e)
+			}
+		}
+	}
+	return list
+}
+
+// buildQuery - Returns the query from the cmdline options, nil when no query options were given.
+func buildQuery(opt *getoptions.GetOpt) (*clitable.Query, error) {
+	q := &clitable.Query{
+		Columns: splitList(opt.Value("cols").([]string)),
+		Sort:    splitList(opt.Value("sort").([]string)),
+		Limit:   opt.Value("limit").(int),
+		GroupBy: splitList(opt.Value("group-by").([]string)),
+		Count:   opt.Value("count").(bool),
+		Sum:     splitList(opt.Value("sum").([]string)),
+	}
+	for _, w := range opt.Value("where").([]string) {
+		c, err := clitable.ParseCondition(w)
+		if err != nil {
+			return nil, err
+		}
+		q.Where = append(q.Where, c)
+	}
+	if len(q.Columns) == 0 && len(q.Where) == 0 && len(q.Sort) == 0 && q.Limit == 0 &&
+		len(q.GroupBy) == 0 && !q.Count && len(q.Sum) == 0 {
+		return nil, nil
+	}
+	return q, nil
+}
+
+func version(semVersion string) (string, error) {
+	var revision, timeStr, modified string
+	info, ok := debug.ReadBuildInfo()
+	if ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.time":
+				vcsTime := s.Value
+				date, err := time.Parse("2006-01-02T15:04:05Z", vcsTime)
+				if err != nil {
+					return "", fmt.Errorf("failed to parse time: %w", err)
+				}
+				timeStr = date.Format("20060102_150405")
+			case "vcs.modified":
+				if s.Value == "true" {
+					modified = "modified"
+				}
+			}
+		}
+	}
+	if revision != "" && timeStr != "" {
+		semVersion += fmt.Sprintf("+%s.%s", revision, timeStr)
+		if modified != "" {
+			semVersion += fmt.Sprintf(".%s", modified)
+		}
+	}
+	return semVersion, nil
+}
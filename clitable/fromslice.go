@@ -0,0 +1,301 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SliceOption - Options for FromSlice.
+type SliceOption func(*sliceOptions)
+
+type sliceOptions struct {
+	columns    []string
+	timeFormat string
+}
+
+// WithColumns - Selects and orders the columns by header or by field path, like "Owner.Name".
+func WithColumns(columns ...string) SliceOption {
+	return func(o *sliceOptions) {
+		o.columns = columns
+	}
+}
+
+// WithTimeFormat - Layout for time.Time values without a format tag, time.RFC3339 by default.
+func WithTimeFormat(layout string) SliceOption {
+	return func(o *sliceOptions) {
+		o.timeFormat = layout
+	}
+}
+
+// sliceColumn - A column extracted from a struct field or a map key.
+type sliceColumn struct {
+	Header string
+	// Go field path, like Owner.Name, or map key
+	Path      string
+	index     []int
+	omitEmpty bool
+	format    string
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// FromSlice - Returns a Table with a header row and a row for each element of v.
+// v must be a slice of structs, maps with string keys, or pointers to them.
+//
+// Struct fields are configured with a clitable tag:
+//
+//	type Instance struct {
+//		ID      string        `clitable:"Instance ID"`
+//		Owner   User          // Nested structs are flattened into Owner.Name, Owner.Email, ...
+//		Parent  *Instance     // Recursive types are not flattened, they are shown in a single column
+//		Created time.Time     `clitable:"Created,format=2006-01-02"`
+//		Uptime  time.Duration `clitable:",format=1s"`
+//		Cost    float64       `clitable:",omitempty,format=%.2f"`
+//		secret  string        // Unexported fields are skipped
+//		Token   string        `clitable:"-"`
+//	}
+//
+// The tag is the header name, defaulting to the field name, followed by options:
+//
+//   - omitempty: Leave the cell empty for zero values.
+//   - format=<format>: A time layout for time.Time, a rounding unit for time.Duration and a fmt verb for other types.
+//     It must be the last option since the format can contain commas.
+func FromSlice(v any, opts ...SliceOption) (Table, error) {
+	o := &sliceOptions{timeFormat: time.RFC3339}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a slice, got: %T", v)
+	}
+	elemType := rv.Type().Elem()
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	var columns []sliceColumn
+	switch elemType.Kind() {
+	case reflect.Struct:
+		columns = structColumns(elemType, nil, "", "", []reflect.Type{elemType})
+	case reflect.Map:
+		if elemType.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("expected map keys of type string, got: %s", elemType.Key())
+		}
+		columns = mapColumns(rv)
+	default:
+		return nil, fmt.Errorf("expected a slice of structs or maps, got: %T", v)
+	}
+
+	if len(o.columns) > 0 {
+		selected := []sliceColumn{}
+		for _, name := range o.columns {
+			found := false
+			for _, c := range columns {
+				if c.Header == name || c.Path == name {
+					selected = append(selected, c)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown column: '%s'", name)
+			}
+		}
+		columns = selected
+	}
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Header
+	}
+	data := [][]string{header}
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		for elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		row := make([]string, len(columns))
+		if elem.IsValid() {
+			for j, c := range columns {
+				var cell reflect.Value
+				if elem.Kind() == reflect.Map {
+					cell = elem.MapIndex(reflect.ValueOf(c.Path).Convert(elem.Type().Key()))
+				} else {
+					cell = fieldByIndex(elem, c.index)
+				}
+				row[j] = formatValue(cell, c, o)
+			}
+		}
+		data = append(data, row)
+	}
+	return SimpleTable{Data: data}, nil
+}
+
+// structColumns - Returns the columns for the exported fields of t, flattening nested structs.
+// parents are the struct types being flattened, a field of one of those types is a single column to stop the recursion.
+func structColumns(t reflect.Type, index []int, pathPrefix, headerPrefix string, parents []reflect.Type) []sliceColumn {
+	columns := []sliceColumn{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// Exported fields of embedded unexported structs are promoted
+		if !f.IsExported() && !(f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		tag := f.Tag.Get("clitable")
+		if tag == "-" {
+			continue
+		}
+		c := sliceColumn{
+			index: append(append([]int{}, index...), i),
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		for options != "" {
+			var option string
+			if strings.HasPrefix(options, "format=") {
+				option, options = options, ""
+			} else {
+				option, options, _ = strings.Cut(options, ",")
+			}
+			switch {
+			case option == "omitempty":
+				c.omitEmpty = true
+			case strings.HasPrefix(option, "format="):
+				c.format = strings.TrimPrefix(option, "format=")
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		c.Path = pathPrefix + f.Name
+		c.Header = headerPrefix + name
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType && !ft.Implements(stringerType) && !reflect.PointerTo(ft).Implements(stringerType) &&
+			!containsType(parents, ft) {
+			p := append(append([]reflect.Type{}, parents...), ft)
+			if f.Anonymous && tag == "" {
+				// Embedded structs are promoted without a prefix
+				columns = append(columns, structColumns(ft, c.index, pathPrefix, headerPrefix, p)...)
+			} else {
+				columns = append(columns, structColumns(ft, c.index, c.Path+".", c.Header+".", p)...)
+			}
+			continue
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, e := range types {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// mapColumns - Returns the sorted union of the keys in the slice of maps.
+func mapColumns(rv reflect.Value) []sliceColumn {
+	seen := map[string]bool{}
+	keys := []string{}
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		for elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if !elem.IsValid() {
+			continue
+		}
+		for _, k := range elem.MapKeys() {
+			key := k.String()
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	columns := []sliceColumn{}
+	for _, k := range keys {
+		columns = append(columns, sliceColumn{Header: k, Path: k})
+	}
+	return columns
+}
+
+// fieldByIndex - Like reflect.Value.FieldByIndex but returns an invalid value on nil pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+func formatValue(v reflect.Value, c sliceColumn, o *sliceOptions) string {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	if c.omitEmpty && v.IsZero() {
+		return ""
+	}
+	switch {
+	case v.Type() == timeType:
+		layout := o.timeFormat
+		if c.format != "" {
+			layout = c.format
+		}
+		return v.Interface().(time.Time).Format(layout)
+	case v.Type() == durationType:
+		d := v.Interface().(time.Duration)
+		if c.format != "" {
+			if unit, err := time.ParseDuration(c.format); err == nil {
+				d = d.Round(unit)
+			}
+		}
+		return d.String()
+	case c.format != "":
+		return fmt.Sprintf(c.format, v.Interface())
+	}
+	if v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String()
+	}
+	if reflect.PointerTo(v.Type()).Implements(stringerType) {
+		// String has a pointer receiver
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface().(fmt.Stringer).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
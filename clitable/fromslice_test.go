@@ -0,0 +1,162 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DavidGamba/dgtools/clitable"
+)
+
+type user struct {
+	Name  string
+	Email string `clitable:"E-mail"`
+}
+
+type level int
+
+func (l *level) String() string {
+	return [...]string{"low", "high"}[*l]
+}
+
+type base struct {
+	ID string `clitable:"Instance ID"`
+}
+
+type instance struct {
+	base
+	Owner   *user
+	Created time.Time     `clitable:",format=2006-01-02 15:04"`
+	Uptime  time.Duration `clitable:",format=1s"`
+	Cost    float64       `clitable:",omitempty,format=%.2f"`
+	Level   level
+	Tags    []string
+	Token   string `clitable:"-"`
+	secret  string
+}
+
+func TestFromSlice(t *testing.T) {
+	created := time.Date(2023, 12, 1, 10, 30, 0, 0, time.UTC)
+	instances := []*instance{
+		{base: base{ID: "i-1"}, Owner: &user{"Ann", "ann@example.com"}, Created: created, Uptime: 90*time.Minute + 1500*time.Millisecond, Cost: 1.234, Level: 1, Tags: []string{"a", "b"}, Token: "x", secret: "y"},
+		{base: base{ID: "i-2"}, Created: created},
+		nil,
+	}
+
+	tests := []struct {
+		name     string
+		opts     []clitable.SliceOption
+		expected [][]string
+		err      bool
+	}{
+		{"all columns", nil, [][]string{
+			{"Instance ID", "Owner.Name", "Owner.E-mail", "Created", "Uptime", "Cost", "Level", "Tags"},
+			{"i-1", "Ann", "ann@example.com", "2023-12-01 10:30", "1h30m2s", "1.23", "high", "[a b]"},
+			{"i-2", "", "", "2023-12-01 10:30", "0s", "", "low", "[]"},
+			{"", "", "", "", "", "", "", ""},
+		}, false},
+		{"selected columns by header and path", []clitable.SliceOption{clitable.WithColumns("Owner.Email", "Instance ID")}, [][]string{
+			{"Owner.E-mail", "Instance ID"},
+			{"ann@example.com", "i-1"},
+			{"", "i-2"},
+			{"", ""},
+		}, false},
+		{"unknown column", []clitable.SliceOption{clitable.WithColumns("Missing")}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := clitable.FromSlice(instances, tt.opts...)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := table.(clitable.SimpleTable).Data
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFromSliceMaps(t *testing.T) {
+	data := []map[string]any{
+		{"name": "a", "count": 1},
+		{"name": "b", "region": "us-east-1"},
+	}
+	table, err := clitable.FromSlice(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := [][]string{
+		{"count", "name", "region"},
+		{"1", "a", ""},
+		{"", "b", "us-east-1"},
+	}
+	got := table.(clitable.SimpleTable).Data
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, got)
+	}
+
+	_, err = clitable.FromSlice("not a slice")
+	if err == nil {
+		t.Errorf("expected error")
+	}
+	_, err = clitable.FromSlice([]int{1})
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+type node struct {
+	Name   string
+	Parent *node
+}
+
+type edge struct {
+	From node
+	To   *node
+}
+
+func TestFromSliceRecursive(t *testing.T) {
+	root := &node{Name: "root"}
+	table, err := clitable.FromSlice([]node{*root, {Name: "child", Parent: root}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := [][]string{
+		{"Name", "Parent"},
+		{"root", ""},
+		{"child", "{root <nil>}"},
+	}
+	got := table.(clitable.SimpleTable).Data
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, got)
+	}
+
+	// The same type in sibling fields is flattened in both
+	table, err = clitable.FromSlice([]edge{{From: node{Name: "a"}, To: &node{Name: "b"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected = [][]string{
+		{"From.Name", "From.Parent", "To.Name", "To.Parent"},
+		{"a", "", "b", ""},
+	}
+	got = table.(clitable.SimpleTable).Data
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, got)
+	}
+}
@@ -4,4 +4,13 @@ go 1.18
 
 require github.com/DavidGamba/go-getoptions v0.29.0
 
-require golang.org/x/text v0.14.0
+require (
+	github.com/nsf/termbox-go v1.1.1
+	golang.org/x/term v0.13.0
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/mattn/go-runewidth v0.0.9 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
@@ -0,0 +1,354 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query - Selects, filters, sorts and aggregates the rows of a table with a header row.
+//
+// The operations are applied in order: Where, GroupBy, Sort, Limit and Columns.
+type Query struct {
+	// Columns to print, in order. All columns when empty.
+	Columns []string
+	// Conditions that all rows must match.
+	Where []Condition
+	// Columns to sort by, prefix with - for descending order.
+	Sort []string
+	// Max number of rows, no limit when 0.
+	Limit int
+
+	// Columns to group by. The result has the group by columns followed by the aggregates.
+	GroupBy []string
+	// Add a count column with the number of rows in each group.
+	Count bool
+	// Add a sum(<column>) column for each of the given columns.
+	Sum []string
+}
+
+// Condition - A comparison between a column and a value.
+type Condition struct {
+	Column string
+	// One of =, !=, <, <=, >, >=, ~ (regex match) or !~ (regex doesn't match)
+	Op    string
+	Value string
+	re    *regexp.Regexp
+}
+
+var conditionOps = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+// ParseCondition - Parses a condition like status=failed, duration>=1m or name~^web.
+// Numbers, dates and durations are compared by value, other values as strings.
+func ParseCondition(s string) (Condition, error) {
+	c := Condition{}
+	// Find the first operator, checking the two char operators first at each position
+	for i := 0; i < len(s); i++ {
+		for _, op := range conditionOps {
+			if strings.HasPrefix(s[i:], op) {
+				c.Column = strings.TrimSpace(s[:i])
+				c.Op = op
+				c.Value = strings.TrimSpace(s[i+len(op):])
+				break
+			}
+		}
+		if c.Op != "" {
+			break
+		}
+	}
+	if c.Op == "" || c.Column == "" {
+		return c, fmt.Errorf("invalid condition '%s': expected <column><op><value> where op is one of: %s", s, strings.Join(conditionOps, " "))
+	}
+	if c.Op == "~" || c.Op == "!~" {
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return c, fmt.Errorf("invalid condition '%s': %w", s, err)
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+func (c Condition) String() string {
+	return c.Column + c.Op + c.Value
+}
+
+// Match - Whether the value matches the condition.
+func (c Condition) Match(value string) bool {
+	switch c.Op {
+	case "~":
+		return c.re.MatchString(StripANSI(value))
+	case "!~":
+		return !c.re.MatchString(StripANSI(value))
+	}
+	cmp := CompareValues(value, c.Value)
+	switch c.Op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// CompareValues - Returns -1, 0 or 1 comparing a and b as numbers, dates or durations when both parse as such, otherwise as strings.
+func CompareValues(a, b string) int {
+	a = strings.TrimSpace(StripANSI(a))
+	b = strings.TrimSpace(StripANSI(b))
+	// ParseFloat also accepts NaN, Inf and hex values, only compare decimal numbers numerically
+	if numberRe.MatchString(a) && numberRe.MatchString(b) {
+		if fa, err := strconv.ParseFloat(a, 64); err == nil {
+			if fb, err := strconv.ParseFloat(b, 64); err == nil {
+				return compareOrdered(fa, fb)
+			}
+		}
+	}
+	if ta, ok := parseDate(a); ok {
+		if tb, ok := parseDate(b); ok {
+			return compareOrdered(ta.UnixNano(), tb.UnixNano())
+		}
+	}
+	if da, err := time.ParseDuration(a); err == nil {
+		if db, err := time.ParseDuration(b); err == nil {
+			return compareOrdered(da, db)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func compareOrdered[T int64 | float64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// columnIndex - Returns the index of the column in the header.
+func columnIndex(header []string, column string) (int, error) {
+	for i, h := range header {
+		if h == column {
+			return i, nil
+		}
+	}
+	for i, h := range header {
+		if strings.EqualFold(h, column) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown column '%s', valid columns: %s", column, strings.Join(header, ", "))
+}
+
+func field(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// Apply - Runs the query on the table, the first row of the table is the header.
+// The result has a header row.
+func (q *Query) Apply(t Table) (Table, error) {
+	var header []string
+	rows := [][]string{}
+	for row := range t.RowIterator() {
+		if row.Error != nil {
+			return nil, row.Error
+		}
+		if header == nil {
+			header = row.Fields
+			continue
+		}
+		rows = append(rows, row.Fields)
+	}
+	if header == nil {
+		return SimpleTable{Data: [][]string{}}, nil
+	}
+
+	// Where
+	for _, c := range q.Where {
+		i, err := columnIndex(header, c.Column)
+		if err != nil {
+			return nil, err
+		}
+		filtered := [][]string{}
+		for _, row := range rows {
+			if c.Match(field(row, i)) {
+				filtered = append(filtered, row)
+			}
+		}
+		rows = filtered
+	}
+
+	// Group by
+	if len(q.GroupBy) > 0 || q.Count || len(q.Sum) > 0 {
+		var err error
+		header, rows, err = q.aggregate(header, rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sort
+	if len(q.Sort) > 0 {
+		type sortKey struct {
+			index int
+			desc  bool
+		}
+		keys := []sortKey{}
+		for _, s := range q.Sort {
+			desc := strings.HasPrefix(s, "-")
+			i, err := columnIndex(header, strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+"))
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, sortKey{i, desc})
+		}
+		sort.SliceStable(rows, func(a, b int) bool {
+			for _, k := range keys {
+				cmp := CompareValues(field(rows[a], k.index), field(rows[b], k.index))
+				if cmp == 0 {
+					continue
+				}
+				if k.desc {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	// Limit
+	if q.Limit > 0 && len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+	}
+
+	// Columns
+	data := append([][]string{header}, rows...)
+	if len(q.Columns) > 0 {
+		indexes := []int{}
+		for _, c := range q.Columns {
+			i, err := columnIndex(header, c)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, i)
+		}
+		for r, row := range data {
+			selected := make([]string, len(indexes))
+			for j, i := range indexes {
+				selected[j] = field(row, i)
+			}
+			data[r] = selected
+		}
+	}
+	return SimpleTable{Data: data}, nil
+}
+
+// aggregate - Groups the rows by the GroupBy columns in order of appearance and adds the Count and Sum aggregates.
+func (q *Query) aggregate(header []string, rows [][]string) ([]string, [][]string, error) {
+	groupIndexes := []int{}
+	newHeader := []string{}
+	for _, c := range q.GroupBy {
+		i, err := columnIndex(header, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		groupIndexes = append(groupIndexes, i)
+		newHeader = append(newHeader, header[i])
+	}
+	sumIndexes := []int{}
+	for _, c := range q.Sum {
+		i, err := columnIndex(header, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		sumIndexes = append(sumIndexes, i)
+	}
+	if q.Count {
+		newHeader = append(newHeader, "count")
+	}
+	for _, i := range sumIndexes {
+		newHeader = append(newHeader, fmt.Sprintf("sum(%s)", header[i]))
+	}
+
+	type group struct {
+		keys  []string
+		count int
+		sums  []float64
+	}
+	groups := map[string]*group{}
+	order := []string{}
+	for _, row := range rows {
+		keys := make([]string, len(groupIndexes))
+		for j, i := range groupIndexes {
+			keys[j] = field(row, i)
+		}
+		id := strings.Join(keys, "\x00")
+		g, ok := groups[id]
+		if !ok {
+			g = &group{keys: keys, sums: make([]float64, len(sumIndexes))}
+			groups[id] = g
+			order = append(order, id)
+		}
+		g.count++
+		for j, i := range sumIndexes {
+			value := strings.TrimSpace(StripANSI(field(row, i)))
+			if value == "" {
+				continue
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || !numberRe.MatchString(value) {
+				return nil, nil, fmt.Errorf("failed to sum column '%s': '%s' is not a number", header[i], value)
+			}
+			g.sums[j] += f
+		}
+	}
+
+	newRows := [][]string{}
+	for _, id := range order {
+		g := groups[id]
+		row := append([]string{}, g.keys...)
+		if q.Count {
+			row = append(row, strconv.Itoa(g.count))
+		}
+		for _, s := range g.sums {
+			row = append(row, strconv.FormatFloat(s, 'f', -1, 64))
+		}
+		newRows = append(newRows, row)
+	}
+	return newHeader, newRows, nil
+}
@@ -0,0 +1,140 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DavidGamba/dgtools/clitable"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		input  string
+		column string
+		op     string
+		value  string
+		err    bool
+	}{
+		{"status=failed", "status", "=", "failed", false},
+		{"count >= 10", "count", ">=", "10", false},
+		{"url=a=b", "url", "=", "a=b", false},
+		{"name!~^web", "name", "!~", "^web", false},
+		{"name~[", "", "", "", true},
+		{"status", "", "", "", true},
+		{"=failed", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			c, err := clitable.ParseCondition(tt.input)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if c.Column != tt.column || c.Op != tt.op || c.Value != tt.value {
+				t.Errorf("expected: %s %s %s, got: %s %s %s", tt.column, tt.op, tt.value, c.Column, c.Op, c.Value)
+			}
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"9", "10", -1},
+		{"1.5", "1.50", 0},
+		{"2023-12-01", "2023-11-30T23:00:00Z", 1},
+		{"90s", "1m", 1},
+		{"b", "a", 1},
+		{"9", "a", -1},
+		{"NaN", "1", 1},
+		{"0x10", "9", -1},
+	}
+	for _, tt := range tests {
+		if got := clitable.CompareValues(tt.a, tt.b); got != tt.expected {
+			t.Errorf("CompareValues(%s, %s): expected %d, got: %d", tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	csvData := `name,region,status,duration
+a,us,failed,10
+b,eu,ok,5
+c,us,failed,30
+d,eu,failed,7.5
+e,us,ok,1
+`
+	condition := func(s string) clitable.Condition {
+		c, err := clitable.ParseCondition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	tests := []struct {
+		name     string
+		q        clitable.Query
+		expected [][]string
+		err      bool
+	}{
+		{"where sort limit cols", clitable.Query{
+			Columns: []string{"name", "duration"},
+			Where:   []clitable.Condition{condition("status=failed")},
+			Sort:    []string{"-duration"},
+			Limit:   2,
+		}, [][]string{{"name", "duration"}, {"c", "30"}, {"a", "10"}}, false},
+		{"multiple conditions", clitable.Query{
+			Where: []clitable.Condition{condition("region=us"), condition("duration<20")},
+		}, [][]string{{"name", "region", "status", "duration"}, {"a", "us", "failed", "10"}, {"e", "us", "ok", "1"}}, false},
+		{"sort by multiple columns", clitable.Query{
+			Columns: []string{"name"},
+			Sort:    []string{"region", "-name"},
+		}, [][]string{{"name"}, {"d"}, {"b"}, {"e"}, {"c"}, {"a"}}, false},
+		{"group by", clitable.Query{
+			GroupBy: []string{"region"},
+			Count:   true,
+			Sum:     []string{"duration"},
+			Sort:    []string{"-count"},
+		}, [][]string{{"region", "count", "sum(duration)"}, {"us", "3", "41"}, {"eu", "2", "12.5"}}, false},
+		{"count without group by", clitable.Query{
+			Where: []clitable.Condition{condition("status=ok")},
+			Count: true,
+		}, [][]string{{"count"}, {"2"}}, false},
+		{"unknown column", clitable.Query{Columns: []string{"missing"}}, nil, true},
+		{"sum non numeric", clitable.Query{GroupBy: []string{"region"}, Sum: []string{"status"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := tt.q.Apply(clitable.CSVTable{Reader: strings.NewReader(csvData)})
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := table.(clitable.SimpleTable).Data
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable
+
+import (
+	"io"
+	"strings"
+)
+
+// Stream - Print rows as they are read instead of reading the whole table first.
+// The column widths are calculated from the first sampleRows rows, later cells that are wider are fit with the column Overflow.
+// Use the Expand overflow to grow the columns instead.
+// Later rows with more columns than the sampled rows add the extra columns from that row on.
+// Memory use is bounded by the sample size, use 0 to disable streaming.
+func (tp *TablePrinter) Stream(sampleRows int) *TablePrinter {
+	tp.streamRows = sampleRows
+	return tp
+}
+
+// streamTable - Replays the sampled rows followed by the rest of the rows.
+// It can only be iterated once.
+type streamTable struct {
+	sample []Row
+	rest   <-chan Row
+}
+
+// RowIterator - Implements the Table interface.
+func (t *streamTable) RowIterator() <-chan Row {
+	c := make(chan Row)
+	go func() {
+		for _, row := range t.sample {
+			c <- row
+		}
+		for row := range t.rest {
+			c <- row
+		}
+		close(c)
+	}()
+	return c
+}
+
+// growColumns - Adds the fields beyond the known columns to the table info.
+// Only needed when streaming since later rows might have more columns than the sampled ones.
+func (i *TableInfo) growColumns(fields []string) {
+	for c := i.Columns; c < len(fields); c++ {
+		width := 0
+		for _, line := range strings.Split(fields[c], "\n") {
+			if l, _ := StringWidth(line); l > width {
+				width = l
+			}
+		}
+		i.ColumnWidths = append(i.ColumnWidths, width)
+		i.NumericColumns = append(i.NumericColumns, false)
+		i.Columns = c + 1
+	}
+}
+
+// fprintStream - Prints the table calculating the table info from the first rows only.
+func (tp *TablePrinter) fprintStream(w io.Writer, t Table) error {
+	rows := t.RowIterator()
+	sample := []Row{}
+	data := [][]string{}
+	for len(sample) < tp.streamRows {
+		row, ok := <-rows
+		if !ok {
+			break
+		}
+		if row.Error != nil {
+			return row.Error
+		}
+		sample = append(sample, row)
+		data = append(data, row.Fields)
+	}
+	tableInfo, err := getTableInfo(SimpleTable{Data: data}, tp.tableConfig.HasHeader)
+	if err != nil {
+		return err
+	}
+	Logger.Printf("sampled tableInfo: %s\n", tableInfo)
+	return tp.render(w, &streamTable{sample: sample, rest: rows}, tableInfo)
+}
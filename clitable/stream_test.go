@@ -0,0 +1,133 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/DavidGamba/dgtools/clitable"
+)
+
+func TestStream(t *testing.T) {
+	input := "Name,ID\na,1\nbb,2\nccccccc,3\n"
+	tests := []struct {
+		name     string
+		tp       *clitable.TablePrinter
+		expected string
+	}{
+		{"same as buffered when the sample has all rows", clitable.NewTablePrinter().Stream(10), `┌─────────┬────┐
+│ Name    │ ID │
+╞═════════╪════╡
+│ a       │ 1  │
+├─────────┼────┤
+│ bb      │ 2  │
+├─────────┼────┤
+│ ccccccc │ 3  │
+└─────────┴────┘
+`},
+		{"wrap", clitable.NewTablePrinter().Stream(2).SetStyle(clitable.Ascii), `+------+----+
+| Name | ID |
++======+====+
+| a    | 1  |
++------+----+
+| bb   | 2  |
++------+----+
+| cccc | 3  |
+| ccc  |    |
++------+----+
+`},
+		{"truncate", clitable.NewTablePrinter().Stream(2).SetStyle(clitable.Ascii).Overflow(clitable.Truncate), `+------+----+
+| Name | ID |
++======+====+
+| a    | 1  |
++------+----+
+| bb   | 2  |
++------+----+
+| ccc… | 3  |
++------+----+
+`},
+		{"expand", clitable.NewTablePrinter().Stream(2).SetStyle(clitable.Ascii).Overflow(clitable.Expand), `+------+----+
+| Name | ID |
++======+====+
+| a    | 1  |
++------+----+
+| bb   | 2  |
++---------+----+
+| ccccccc | 3  |
++---------+----+
+`},
+		{"csv", clitable.NewTablePrinter().Stream(1).SetStyle(clitable.CSV), "Name,ID\na,1\nbb,2\nccccccc,3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := tt.tp.FprintCSVReader(buf, strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestStreamExtraColumns(t *testing.T) {
+	input := "a,b\n1,2,3\n"
+	tests := []struct {
+		name     string
+		tp       *clitable.TablePrinter
+		expected string
+	}{
+		{"ascii", clitable.NewTablePrinter().Stream(1).SetStyle(clitable.Ascii), `+---+---+
+| a | b |
++===+===+===+
+| 1 | 2 | 3 |
++---+---+---+
+`},
+		{"json", clitable.NewTablePrinter().Stream(1).SetStyle(clitable.JSON), `[
+  {"a": "1", "b": "2", "3": "3"}
+]
+`},
+		{"csv", clitable.NewTablePrinter().Stream(1).SetStyle(clitable.CSV), "a,b\n1,2,3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := tt.tp.FprintCSVReader(buf, strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := clitable.NewTablePrinter().Stream(1).FprintCSVReader(buf, strings.NewReader("a\n\"b\n"))
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestFprintCSVReaderWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := clitable.NewTablePrinter().SetStyle(clitable.CSV).FprintCSVReader(buf, strings.NewReader("a,b\n1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if buf.String() != "a,b\n1,\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -0,0 +1,267 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var styleNames = map[Style]string{
+	Full:     "full",
+	Ascii:    "ascii",
+	Compact:  "compact",
+	Space:    "space",
+	CSV:      "csv",
+	TSV:      "tsv",
+	Markdown: "markdown",
+	HTML:     "html",
+	JSON:     "json",
+}
+
+// StyleNames - Names of the styles accepted by ParseStyle.
+var StyleNames = []string{"full", "ascii", "compact", "space", "csv", "tsv", "markdown", "html", "json"}
+
+func (s Style) String() string {
+	if name, ok := styleNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Style(%d)", int(s))
+}
+
+// ParseStyle - Returns the style for the given name, like markdown.
+func ParseStyle(name string) (Style, error) {
+	for s, n := range styleNames {
+		if strings.EqualFold(n, name) {
+			return s, nil
+		}
+	}
+	return Full, fmt.Errorf("unknown style '%s', valid styles: %s", name, strings.Join(StyleNames, ", "))
+}
+
+// tsvReplacer - Escapes backslashes, tabs and newlines so each field stays in its column and each row in a single line.
+var tsvReplacer = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// fprintTSV - Prints tab separated fields without quoting.
+// Backslashes, tabs and newlines in fields are escaped as \\, \t and \n.
+func (tp *TablePrinter) fprintTSV(w io.Writer, t Table, tableInfo *TableInfo) error {
+	for row := range t.RowIterator() {
+		if row.Error != nil {
+			return row.Error
+		}
+		// Rows are padded to the number of columns, when streaming later rows might have more
+		fields := make([]string, len(row.Fields))
+		if len(fields) < tableInfo.Columns {
+			fields = make([]string, tableInfo.Columns)
+		}
+		for i, f := range row.Fields {
+			fields[i] = tsvReplacer.Replace(f)
+		}
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+	}
+	return nil
+}
+
+// markdownCell - Escapes the cell for a GitHub flavored Markdown table.
+func markdownCell(s string) string {
+	s = StripANSI(s)
+	s = strings.ReplaceAll(s, `|`, `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// fprintMarkdown - Prints a GitHub flavored Markdown table.
+// Markdown tables require a header, an empty one is printed when the table doesn't have one.
+func (tp *TablePrinter) fprintMarkdown(w io.Writer, t Table, tableInfo *TableInfo) error {
+	printRow := func(fields []string) {
+		cells := make([]string, tableInfo.Columns)
+		for i := range cells {
+			if i < len(fields) {
+				cells[i] = markdownCell(fields[i])
+			}
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+	printDivider := func() {
+		cells := make([]string, tableInfo.Columns)
+		for i := range cells {
+			switch tp.columnAlignment(i, tableInfo) {
+			case Right:
+				cells[i] = "---:"
+			case Center:
+				cells[i] = ":---:"
+			default:
+				cells[i] = "---"
+			}
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	first := true
+	for row := range t.RowIterator() {
+		if row.Error != nil {
+			return row.Error
+		}
+		tableInfo.growColumns(row.Fields)
+		if first {
+			first = false
+			if tp.tableConfig.HasHeader {
+				printRow(row.Fields)
+				printDivider()
+				continue
+			}
+			printRow(nil)
+			printDivider()
+		}
+		printRow(row.Fields)
+	}
+	return nil
+}
+
+func htmlCell(s string) string {
+	return strings.ReplaceAll(html.EscapeString(StripANSI(s)), "\n", "<br>")
+}
+
+// fprintHTML - Prints an HTML table, the first row goes in the thead when the table has a header.
+func (tp *TablePrinter) fprintHTML(w io.Writer, t Table, tableInfo *TableInfo) error {
+	printRow := func(fields []string, tag string) {
+		fmt.Fprintf(w, "    <tr>\n")
+		for i := 0; i < tableInfo.Columns; i++ {
+			cell := ""
+			if i < len(fields) {
+				cell = htmlCell(fields[i])
+			}
+			style := ""
+			switch tp.columnAlignment(i, tableInfo) {
+			case Right:
+				style = ` style="text-align: right"`
+			case Center:
+				style = ` style="text-align: center"`
+			}
+			fmt.Fprintf(w, "      <%s%s>%s</%s>\n", tag, style, cell, tag)
+		}
+		fmt.Fprintf(w, "    </tr>\n")
+	}
+
+	fmt.Fprintf(w, "<table>\n")
+	first := true
+	for row := range t.RowIterator() {
+		if row.Error != nil {
+			return row.Error
+		}
+		tableInfo.growColumns(row.Fields)
+		if first {
+			first = false
+			if tp.tableConfig.HasHeader {
+				fmt.Fprintf(w, "  <thead>\n")
+				printRow(row.Fields, "th")
+				fmt.Fprintf(w, "  </thead>\n")
+				fmt.Fprintf(w, "  <tbody>\n")
+				continue
+			}
+			fmt.Fprintf(w, "  <tbody>\n")
+		}
+		printRow(row.Fields, "td")
+	}
+	if !first {
+		fmt.Fprintf(w, "  </tbody>\n")
+	}
+	fmt.Fprintf(w, "</table>\n")
+	return nil
+}
+
+// fprintJSON - Prints a JSON array with an element per row.
+// With a header, each element is an object keyed by the header in column order, otherwise an array of strings.
+// Fields without a header are keyed by their column number starting at 1.
+// Repeated header names get a _2, _3, ... suffix so the keys are unique.
+func (tp *TablePrinter) fprintJSON(w io.Writer, t Table, tableInfo *TableInfo) error {
+	var header []string
+	keys := []string{}
+	seen := map[string]bool{}
+	// key - Returns the unique key for column i
+	key := func(i int) string {
+		for len(keys) <= i {
+			c := len(keys)
+			k := strconv.Itoa(c + 1)
+			if c < len(header) && header[c] != "" {
+				k = header[c]
+			}
+			unique := k
+			for n := 2; seen[unique]; n++ {
+				unique = fmt.Sprintf("%s_%d", k, n)
+			}
+			seen[unique] = true
+			keys = append(keys, unique)
+		}
+		return keys[i]
+	}
+	first := true
+	fmt.Fprint(w, "[")
+	for row := range t.RowIterator() {
+		if row.Error != nil {
+			return row.Error
+		}
+		tableInfo.growColumns(row.Fields)
+		fields := make([]string, len(row.Fields))
+		for i, f := range row.Fields {
+			fields[i] = StripANSI(f)
+		}
+		if tp.tableConfig.HasHeader && header == nil {
+			header = fields
+			continue
+		}
+		if !first {
+			fmt.Fprint(w, ",")
+		}
+		first = false
+		fmt.Fprint(w, "\n  ")
+
+		if !tp.tableConfig.HasHeader {
+			data, err := marshalJSON(fields)
+			if err != nil {
+				return fmt.Errorf("failed to marshal row: %w", err)
+			}
+			w.Write(data)
+			continue
+		}
+		// Build the object by hand to keep the column order
+		fmt.Fprint(w, "{")
+		for i := 0; i < tableInfo.Columns; i++ {
+			value := ""
+			if i < len(fields) {
+				value = fields[i]
+			}
+			k, _ := marshalJSON(key(i))
+			v, _ := marshalJSON(value)
+			if i > 0 {
+				fmt.Fprint(w, ", ")
+			}
+			fmt.Fprintf(w, "%s: %s", k, v)
+		}
+		fmt.Fprint(w, "}")
+	}
+	if !first {
+		fmt.Fprint(w, "\n")
+	}
+	fmt.Fprint(w, "]\n")
+	return nil
+}
+
+// marshalJSON - Like json.Marshal without escaping <, > and &.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+}
@@ -0,0 +1,137 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DavidGamba/dgtools/clitable"
+)
+
+func TestStyles(t *testing.T) {
+	data := [][]string{
+		{"Name", "Count"},
+		{"a|b", "1"},
+		{"<c>\nd", "20"},
+	}
+	tests := []struct {
+		name     string
+		tp       *clitable.TablePrinter
+		expected string
+	}{
+		{"markdown", clitable.NewTablePrinter().SetStyle(clitable.Markdown).Align(clitable.Auto), `| Name | Count |
+| --- | ---: |
+| a\|b | 1 |
+| <c><br>d | 20 |
+`},
+		{"markdown no header", clitable.NewTablePrinter().SetStyle(clitable.Markdown).HasHeader(false), `|  |  |
+| --- | --- |
+| Name | Count |
+| a\|b | 1 |
+| <c><br>d | 20 |
+`},
+		{"html", clitable.NewTablePrinter().SetStyle(clitable.HTML), `<table>
+  <thead>
+    <tr>
+      <th>Name</th>
+      <th>Count</th>
+    </tr>
+  </thead>
+  <tbody>
+    <tr>
+      <td>a|b</td>
+      <td>1</td>
+    </tr>
+    <tr>
+      <td>&lt;c&gt;<br>d</td>
+      <td>20</td>
+    </tr>
+  </tbody>
+</table>
+`},
+		{"json", clitable.NewTablePrinter().SetStyle(clitable.JSON), `[
+  {"Name": "a|b", "Count": "1"},
+  {"Name": "<c>\nd", "Count": "20"}
+]
+`},
+		{"json no header", clitable.NewTablePrinter().SetStyle(clitable.JSON).HasHeader(false), `[
+  ["Name","Count"],
+  ["a|b","1"],
+  ["<c>\nd","20"]
+]
+`},
+		{"tsv", clitable.NewTablePrinter().SetStyle(clitable.TSV), "Name\tCount\na|b\t1\n<c>\\nd\t20\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := tt.tp.Fprint(buf, clitable.SimpleTable{Data: data})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestStylesEmpty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := clitable.NewTablePrinter().SetStyle(clitable.JSON).Fprint(buf, clitable.SimpleTable{Data: [][]string{{"Name"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("expected empty array, got: %q", buf.String())
+	}
+}
+
+func TestStylesEscaping(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := clitable.NewTablePrinter().SetStyle(clitable.TSV).Fprint(buf, clitable.SimpleTable{Data: [][]string{{`x"y`, "a\tb", `c\d`}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "x\"y\ta\\tb\tc\\\\d\n"
+	if buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+
+	// Repeated header names are made unique
+	buf.Reset()
+	err = clitable.NewTablePrinter().SetStyle(clitable.JSON).Fprint(buf, clitable.SimpleTable{Data: [][]string{{"Name", "Name", ""}, {"a", "b", "c"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected = `[
+  {"Name": "a", "Name_2": "b", "3": "c"}
+]
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	for _, name := range clitable.StyleNames {
+		s, err := clitable.ParseStyle(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if s.String() != name {
+			t.Errorf("expected: %s, got: %s", name, s)
+		}
+	}
+	_, err := clitable.ParseStyle("unknown")
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -25,11 +25,13 @@ type TableInfo struct {
 	PerRowRows         [][]int // Number of Lines in a Row due to multiline entries.
 	ColumnWidths       []int
 	RowHeights         []int
+	// Columns where all the non empty values, excluding the header, are numbers.
+	NumericColumns []bool
 }
 
 func (i *TableInfo) String() string {
-	str := fmt.Sprintf("%d Rows x %d Columns\nColumn widths: %v, Row heights: %v\nPerRowColumnWidths: %v\nPerRowRows: %v\n",
-		i.Rows, i.Columns, i.ColumnWidths, i.RowHeights, i.PerRowColumnWidths, i.PerRowRows)
+	str := fmt.Sprintf("%d Rows x %d Columns\nColumn widths: %v, Row heights: %v, Numeric columns: %v\nPerRowColumnWidths: %v\nPerRowRows: %v\n",
+		i.Rows, i.Columns, i.ColumnWidths, i.RowHeights, i.NumericColumns, i.PerRowColumnWidths, i.PerRowRows)
 	return str
 }
 
@@ -42,8 +44,10 @@ var single_width_table = []*unicode.RangeTable{
 	}},
 }
 
+// StringWidth - Returns the number of columns used to display s and how many of its characters are double width.
+// ANSI SGR escape sequences are not counted.
 func StringWidth(s string) (width int, doubleWidthCount int) {
-	normalized := string(norm.NFC.Bytes([]byte(s)))
+	normalized := string(norm.NFC.Bytes([]byte(StripANSI(s))))
 	l := len(normalized)                    // string len check, will count 2 or 3 for a single emoji
 	u := utf8.RuneCountInString(normalized) // rune count, will correctly count number of chars
 	diff := l - u                           // diff
@@ -64,13 +68,22 @@ func StringWidth(s string) (width int, doubleWidthCount int) {
 }
 
 // GetTableInfo - Iterates over all the elements of the table to get number of Colums, Colum widths, etc.
+// The first row is considered the header.
 func GetTableInfo(t Table) (*TableInfo, error) {
+	return getTableInfo(t, true)
+}
+
+// getTableInfo - Same as GetTableInfo, header controls whether the first row is excluded from the numeric column detection.
+func getTableInfo(t Table, header bool) (*TableInfo, error) {
 	var rows int
 	var columns int
 	var perRowColumnWidths [][]int
 	var perRowRows [][]int
 	var columnWidths []int
 	var rowHeights []int
+	// Track the columns with values and the ones with non numeric values to detect numeric columns
+	var hasValues []bool
+	var nonNumeric []bool
 	for row := range t.RowIterator() {
 		if row.Error != nil {
 			return &TableInfo{}, row.Error
@@ -80,6 +93,10 @@ func GetTableInfo(t Table) (*TableInfo, error) {
 		if rowColumns > columns {
 			columns = rowColumns
 		}
+		for len(hasValues) < columns {
+			hasValues = append(hasValues, false)
+			nonNumeric = append(nonNumeric, false)
+		}
 		// Get Column Widths for this row
 		rowColumnWidths := make([]int, rowColumns)
 		rowRows := make([]int, rowColumns)
@@ -95,6 +112,12 @@ func GetTableInfo(t Table) (*TableInfo, error) {
 			}
 			rowColumnWidths[i] = ll
 			rowRows[i] = len(multiLine)
+			if (rows > 0 || !header) && strings.TrimSpace(cData) != "" {
+				hasValues[i] = true
+				if !isNumber(cData) {
+					nonNumeric[i] = true
+				}
+			}
 		}
 		perRowColumnWidths = append(perRowColumnWidths, rowColumnWidths)
 		perRowRows = append(perRowRows, rowRows)
@@ -125,7 +148,12 @@ func GetTableInfo(t Table) (*TableInfo, error) {
 			}
 		}
 	}
+	numericColumns := make([]bool, columns)
+	for c := 0; c < columns; c++ {
+		numericColumns[c] = hasValues[c] && !nonNumeric[c]
+	}
 	return &TableInfo{
+		NumericColumns:     numericColumns,
 		Rows:               rows,
 		Columns:            columns,
 		PerRowColumnWidths: perRowColumnWidths,
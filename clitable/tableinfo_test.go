@@ -36,6 +36,7 @@ func Test_GetTableInfo(t *testing.T) {
 				PerRowRows:         [][]int{{1}},
 				ColumnWidths:       []int{5},
 				RowHeights:         []int{1},
+				NumericColumns:     []bool{false},
 			},
 			false},
 		{"single column, single row emoji ⚽⛪Å®",
@@ -47,6 +48,7 @@ func Test_GetTableInfo(t *testing.T) {
 				PerRowRows:         [][]int{{1}},
 				ColumnWidths:       []int{12},
 				RowHeights:         []int{1},
+				NumericColumns:     []bool{false},
 			},
 			false},
 		{"multi column, single row",
@@ -58,6 +60,7 @@ func Test_GetTableInfo(t *testing.T) {
 				PerRowRows:         [][]int{{1, 1}},
 				ColumnWidths:       []int{1, 2},
 				RowHeights:         []int{1},
+				NumericColumns:     []bool{false, false},
 			},
 			false},
 		{"multi column, multi row",
@@ -70,6 +73,7 @@ ccc,dddd`)},
 				PerRowRows:         [][]int{{1, 1}, {1, 1}},
 				ColumnWidths:       []int{3, 4},
 				RowHeights:         []int{1, 1},
+				NumericColumns:     []bool{false, false},
 			},
 			false},
 		{"multi column, multi row, uneven rows",
@@ -82,6 +86,7 @@ ccc,dddd`)},
 				PerRowRows:         [][]int{{1}, {1, 1}},
 				ColumnWidths:       []int{3, 4},
 				RowHeights:         []int{1, 1},
+				NumericColumns:     []bool{false, false},
 			},
 			false},
 		{"multi column, multi row, multiline column",
@@ -96,6 +101,19 @@ dddd,ee,fff`)},
 				PerRowRows:         [][]int{{1, 3, 1}, {1, 1, 1}},
 				ColumnWidths:       []int{4, 5, 3},
 				RowHeights:         []int{3, 1},
+				NumericColumns:     []bool{false, false, false},
+			},
+			false},
+		{"numeric column",
+			args{bytes.NewBufferString("name,count\na,1\nb,-2.5\nc,\n")},
+			&clitable.TableInfo{
+				Columns:            2,
+				Rows:               4,
+				PerRowColumnWidths: [][]int{{4, 5}, {1, 1}, {1, 4}, {1, 0}},
+				PerRowRows:         [][]int{{1, 1}, {1, 1}, {1, 1}, {1, 1}},
+				ColumnWidths:       []int{4, 5},
+				RowHeights:         []int{1, 1, 1, 1},
+				NumericColumns:     []bool{false, true},
 			},
 			false},
 		{"bad input",
@@ -108,7 +126,7 @@ dddd,ee,fff`)},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := clitable.GetTableInfo(clitable.CSVTable{tt.args.reader})
+			got, err := clitable.GetTableInfo(clitable.CSVTable{Reader: tt.args.reader})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetTableInfo() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -140,6 +158,7 @@ func TestGetTableInfoSimpleTable(t *testing.T) {
 				PerRowRows:         [][]int{{1}},
 				ColumnWidths:       []int{1},
 				RowHeights:         []int{1},
+				NumericColumns:     []bool{false},
 			},
 			false,
 		},
@@ -153,6 +172,7 @@ func TestGetTableInfoSimpleTable(t *testing.T) {
 				PerRowRows:         [][]int{{1, 1}, {1, 1}},
 				ColumnWidths:       []int{1, 1},
 				RowHeights:         []int{1, 1},
+				NumericColumns:     []bool{false, false},
 			},
 			false,
 		},
@@ -0,0 +1,287 @@
+// This file is part of clitable.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package clitable
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Overflow - How to fit a cell wider than its column.
+type Overflow int
+
+const (
+	// Wrap - Word wrap the cell into multiple lines.
+	Wrap Overflow = iota
+	// Truncate - Cut the cell and add an ellipsis.
+	Truncate
+	// Expand - Grow the column, it is never shrunk to fit the table width.
+	// When streaming, the column grows from the first row that is wider than the sampled rows.
+	Expand
+)
+
+const ellipsis = "…"
+
+func (tp *TablePrinter) columnOverflow(column int) Overflow {
+	if o, ok := tp.columnOverflows[column]; ok {
+		return o
+	}
+	return tp.overflow
+}
+
+// tableWidth - Returns the max width for the table, 0 when there is no limit.
+func (tp *TablePrinter) tableWidth(w io.Writer) int {
+	if tp.maxWidth != 0 {
+		if tp.maxWidth < 0 {
+			return 0
+		}
+		return tp.maxWidth
+	}
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		width, _, err := term.GetSize(int(f.Fd()))
+		if err == nil && width > 0 {
+			return width
+		}
+	}
+	return 0
+}
+
+// fitColumnWidths - Returns the column widths after applying the column max widths and shrinking the widest columns to fit the table width.
+func (tp *TablePrinter) fitColumnWidths(w io.Writer, tableInfo *TableInfo) []int {
+	widths := make([]int, len(tableInfo.ColumnWidths))
+	copy(widths, tableInfo.ColumnWidths)
+	for i := range widths {
+		if max, ok := tp.columnMaxWidths[i]; ok && max > 0 && widths[i] > max {
+			widths[i] = max
+		}
+	}
+
+	maxWidth := tp.tableWidth(w)
+	if maxWidth <= 0 || len(widths) == 0 {
+		return widths
+	}
+	// Each column has a space on each side and there is a separator between columns
+	overhead := 3*len(widths) - 1
+	if tp.tableConfig.ColumnEdges {
+		overhead += 2
+	}
+	available := maxWidth - overhead
+	total := 0
+	for i, width := range widths {
+		total += width
+		if tp.columnOverflow(i) == Expand {
+			available -= width
+		}
+	}
+	if total <= maxWidth-overhead {
+		return widths
+	}
+
+	// Find the largest cap for the column widths that fits, leaving narrow columns untouched
+	capped := func(limit int) int {
+		sum := 0
+		for i, width := range widths {
+			if tp.columnOverflow(i) == Expand {
+				continue
+			}
+			if width > limit {
+				width = limit
+			}
+			sum += width
+		}
+		return sum
+	}
+	limit := 1
+	for limit+1 <= available && capped(limit+1) <= available {
+		limit++
+	}
+	leftover := available - capped(limit)
+	for i, width := range widths {
+		if width <= limit || tp.columnOverflow(i) == Expand {
+			continue
+		}
+		widths[i] = limit
+		// Hand out the remaining space to the capped columns
+		if leftover > 0 {
+			widths[i]++
+			leftover--
+		}
+	}
+	return widths
+}
+
+// fitCell - Wraps or truncates every line in the cell to the given width.
+func fitCell(s string, width int, o Overflow) string {
+	if width <= 0 {
+		return s
+	}
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if l, _ := StringWidth(line); l <= width {
+			lines = append(lines, line)
+			continue
+		}
+		switch o {
+		case Expand:
+			lines = append(lines, line)
+		case Truncate:
+			lines = append(lines, truncateLine(line, width))
+		default:
+			lines = append(lines, wrapLine(line, width)...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TruncateString - Cuts s to fit in width adding an ellipsis when it doesn't fit.
+// Newlines are shown as ↵ so the result is a single line.
+func TruncateString(s string, width int) string {
+	s = strings.ReplaceAll(s, "\n", "↵")
+	if l, _ := StringWidth(s); l <= width || width <= 0 {
+		return s
+	}
+	return truncateLine(s, width)
+}
+
+func runeWidth(r rune) int {
+	l, _ := StringWidth(string(r))
+	return l
+}
+
+// truncateLine - Cuts the line to fit in width including the ellipsis.
+// Color escape sequences are kept and reset at the end.
+func truncateLine(line string, width int) string {
+	var b strings.Builder
+	used := 0
+	cut := false
+	for _, token := range ansiTokens(norm.NFC.String(line)) {
+		if strings.HasPrefix(token, "\x1b") {
+			if !cut {
+				b.WriteString(token)
+			}
+			continue
+		}
+		rw := runeWidth([]rune(token)[0])
+		if cut || used+rw > width-1 {
+			cut = true
+			continue
+		}
+		b.WriteString(token)
+		used += rw
+	}
+	b.WriteString(ellipsis)
+	if strings.Contains(line, "\x1b") {
+		b.WriteString(sgrReset)
+	}
+	return b.String()
+}
+
+// wrapLine - Word wraps the line to width, words wider than width are split.
+func wrapLine(line string, width int) []string {
+	lines := []string{}
+	current := ""
+	currentWidth := 0
+	for _, word := range strings.Fields(norm.NFC.String(line)) {
+		wordWidth, _ := StringWidth(word)
+		if currentWidth > 0 && currentWidth+1+wordWidth <= width {
+			current += " " + word
+			currentWidth += 1 + wordWidth
+			continue
+		}
+		if currentWidth > 0 {
+			lines = append(lines, current)
+			current, currentWidth = "", 0
+		}
+		for _, piece := range splitWord(word, width) {
+			if current != "" {
+				lines = append(lines, current)
+			}
+			current = piece
+			currentWidth, _ = StringWidth(piece)
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return carryANSI(lines)
+}
+
+// splitWord - Splits the word into pieces of at most width.
+// A double width rune is placed on its own when width is 1.
+func splitWord(word string, width int) []string {
+	pieces := []string{}
+	var b strings.Builder
+	used := 0
+	for _, token := range ansiTokens(word) {
+		rw := 0
+		if !strings.HasPrefix(token, "\x1b") {
+			rw = runeWidth([]rune(token)[0])
+		}
+		if used > 0 && rw > 0 && used+rw > width {
+			pieces = append(pieces, b.String())
+			b.Reset()
+			used = 0
+		}
+		b.WriteString(token)
+		used += rw
+	}
+	if b.Len() > 0 {
+		pieces = append(pieces, b.String())
+	}
+	return pieces
+}
+
+const sgrReset = "\x1b[0m"
+
+// ansiTokens - Splits s into color escape sequences and single runes.
+func ansiTokens(s string) []string {
+	tokens := []string{}
+	last := 0
+	addRunes := func(part string) {
+		for _, r := range part {
+			tokens = append(tokens, string(r))
+		}
+	}
+	for _, loc := range sgrRe.FindAllStringIndex(s, -1) {
+		addRunes(s[last:loc[0]])
+		tokens = append(tokens, s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	addRunes(s[last:])
+	return tokens
+}
+
+// carryANSI - Resets the colors active at the end of each line and restores them at the start of the next one.
+// Otherwise the colors would bleed into the table borders.
+func carryANSI(lines []string) []string {
+	active := ""
+	for i, line := range lines {
+		if !strings.Contains(line, "\x1b") && active == "" {
+			continue
+		}
+		prefix := active
+		for _, seq := range sgrRe.FindAllString(line, -1) {
+			if seq == sgrReset || seq == "\x1b[m" {
+				active = ""
+			} else {
+				active += seq
+			}
+		}
+		line = prefix + line
+		if active != "" {
+			line += sgrReset
+		}
+		lines[i] = line
+	}
+	return lines
+}
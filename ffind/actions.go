@@ -0,0 +1,155 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/DavidGamba/dgtools/ffind/lib/ffind"
+)
+
+// Max size of the arguments passed to a single --exec-batch command.
+const maxBatchArgsSize = 128 * 1024
+
+// extractExec - Removes the --exec and --exec-batch commands from the args.
+// Commands end with a ; or + argument, or at the end of the args.
+func extractExec(args []string) ([]string, []string, []string, error) {
+	remaining := []string{}
+	var execCmd, batchCmd []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if !strings.HasPrefix(args[i], "-") || (name != "exec" && name != "exec-batch") {
+			remaining = append(remaining, args[i])
+			continue
+		}
+		cmd := []string{}
+		for i++; i < len(args) && args[i] != ";" && args[i] != "+"; i++ {
+			cmd = append(cmd, args[i])
+		}
+		if len(cmd) == 0 {
+			return nil, nil, nil, fmt.Errorf("missing command for --%s", name)
+		}
+		if name == "exec" {
+			execCmd = cmd
+		} else {
+			batchCmd = cmd
+		}
+	}
+	return remaining, execCmd, batchCmd, nil
+}
+
+// joinNegativeValues - Joins options with values starting with - into --option=value so they are not parsed as options.
+// For example: --mtime -2d, --size -10k.
+func joinNegativeValues(args []string, options ...string) []string {
+	isOption := map[string]bool{}
+	for _, o := range options {
+		isOption[o] = true
+	}
+	result := []string{}
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if i+1 < len(args) && strings.HasPrefix(args[i], "-") && isOption[name] && strings.HasPrefix(args[i+1], "-") {
+			result = append(result, "--"+name+"="+args[i+1])
+			i++
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result
+}
+
+// execCommand - Returns the command args with {} replaced by path, the path is appended when there is no {}.
+func execCommand(cmd []string, path string) []string {
+	args := []string{}
+	found := false
+	for _, a := range cmd {
+		if strings.Contains(a, "{}") {
+			found = true
+			a = strings.ReplaceAll(a, "{}", path)
+		}
+		args = append(args, a)
+	}
+	if !found {
+		args = append(args, path)
+	}
+	return args
+}
+
+// batchCommands - Returns the commands to run with the {} argument replaced by the paths, the paths are appended when there is no {}.
+// The paths are split into multiple commands to keep the arguments under maxBatchArgsSize.
+func batchCommands(cmd []string, paths []string) [][]string {
+	commands := [][]string{}
+	for len(paths) > 0 {
+		n, size := 0, 0
+		for n < len(paths) && (n == 0 || size+len(paths[n]) < maxBatchArgsSize) {
+			size += len(paths[n]) + 1
+			n++
+		}
+		args := []string{}
+		found := false
+		for _, a := range cmd {
+			if a == "{}" {
+				found = true
+				args = append(args, paths[:n]...)
+				continue
+			}
+			args = append(args, a)
+		}
+		if !found {
+			args = append(args, paths[:n]...)
+		}
+		commands = append(commands, args)
+		paths = paths[n:]
+	}
+	return commands
+}
+
+// runCommand - Runs the command with the ffind stdin, stdout and stderr.
+func runCommand(args []string) error {
+	Logger.Printf("exec: %v\n", args)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to run '%s': %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// jsonResult - Result printed with --json.
+type jsonResult struct {
+	Path  string    `json:"path"`
+	Size  int64     `json:"size"`
+	Mode  string    `json:"mode"`
+	Mtime time.Time `json:"mtime"`
+}
+
+// printJSON - Prints the result as a JSON line.
+func printJSON(w io.Writer, path string, e ffind.FileError) error {
+	r := jsonResult{Path: path}
+	if e.FileInfo != nil {
+		r.Size = e.FileInfo.Size()
+		r.Mode = e.FileInfo.Mode().String()
+		r.Mtime = e.FileInfo.ModTime()
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
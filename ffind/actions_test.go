@@ -0,0 +1,111 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractExec(t *testing.T) {
+	remaining, execCmd, batchCmd, err := extractExec([]string{"pattern", "--exec", "echo", "{}", ";", "-exec-batch", "wc", "-l", "+", "dir/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(remaining, []string{"pattern", "dir/"}) {
+		t.Errorf("unexpected remaining: %v", remaining)
+	}
+	if !reflect.DeepEqual(execCmd, []string{"echo", "{}"}) {
+		t.Errorf("unexpected exec: %v", execCmd)
+	}
+	if !reflect.DeepEqual(batchCmd, []string{"wc", "-l"}) {
+		t.Errorf("unexpected exec-batch: %v", batchCmd)
+	}
+
+	_, _, _, err = extractExec([]string{"--exec", ";"})
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestJoinNegativeValues(t *testing.T) {
+	got := joinNegativeValues([]string{"--mtime", "-2d", "--size", "+1M", "-size", "-1k", "--json", "-0"}, "size", "mtime")
+	expected := []string{"--mtime=-2d", "--size", "+1M", "--size=-1k", "--json", "-0"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestExecCommands(t *testing.T) {
+	if got := execCommand([]string{"mv", "{}", "{}.bak"}, "a"); !reflect.DeepEqual(got, []string{"mv", "a", "a.bak"}) {
+		t.Errorf("unexpected command: %v", got)
+	}
+	if got := execCommand([]string{"ls", "-l"}, "a"); !reflect.DeepEqual(got, []string{"ls", "-l", "a"}) {
+		t.Errorf("unexpected command: %v", got)
+	}
+
+	if got := batchCommands([]string{"tar", "cf", "out.tar", "{}"}, []string{"a", "b"}); !reflect.DeepEqual(got, [][]string{{"tar", "cf", "out.tar", "a", "b"}}) {
+		t.Errorf("unexpected commands: %v", got)
+	}
+	long := strings.Repeat("x", maxBatchArgsSize/2)
+	got := batchCommands([]string{"wc"}, []string{long, long, "a"})
+	if len(got) != 2 || len(got[0]) != 2 || len(got[1]) != 3 {
+		t.Errorf("unexpected commands: %d", len(got))
+	}
+}
+
+func TestExecSkipsErrors(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "file"), []byte(""), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A symlink to itself can't be resolved
+	err = os.Symlink("self", filepath.Join(dir, "self"))
+	if err != nil {
+		t.Skipf("symlinks not supported: %s", err)
+	}
+	if code := program([]string{"ffind", dir + "/", "--exec", "touch", "{}.seen", ";"}); code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "file.seen")); err != nil {
+		t.Errorf("expected exec to run on file: %s", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "self.seen")); err == nil {
+		t.Errorf("exec ran on the entry with an error")
+	}
+}
+
+func TestExecSkipsLoops(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "a", "b"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "a", "b", "file"), []byte(""), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink("..", filepath.Join(dir, "a", "b", "up"))
+	if err != nil {
+		t.Skipf("symlinks not supported: %s", err)
+	}
+	if code := program([]string{"ffind", "--type", "f", dir + "/", "--exec", "touch", "{}.seen", ";"}); code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a", "b", "file.seen")); err != nil {
+		t.Errorf("expected exec to run on file: %s", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "a", "b", "up.seen")); err == nil {
+		t.Errorf("exec ran on the loop")
+	}
+}
@@ -92,19 +92,20 @@ var defaultTypes = map[string][]string{
 	"less":         {"*.less"},
 
 	"license": {
+		// The - in the char classes is escaped for path.Match
 		// General
-		"COPYING", "COPYING[.-]*",
-		"COPYRIGHT", "COPYRIGHT[.-]*",
-		"EULA", "EULA[.-]*",
+		"COPYING", "COPYING[.\\-]*",
+		"COPYRIGHT", "COPYRIGHT[.\\-]*",
+		"EULA", "EULA[.\\-]*",
 		"licen[cs]e", "licen[cs]e.*",
-		"LICEN[CS]E", "LICEN[CS]E[.-]*", "*[.-]LICEN[CS]E*",
-		"NOTICE", "NOTICE[.-]*",
-		"PATENTS", "PATENTS[.-]*",
-		"UNLICEN[CS]E", "UNLICEN[CS]E[.-]*",
+		"LICEN[CS]E", "LICEN[CS]E[.\\-]*", "*[.\\-]LICEN[CS]E*",
+		"NOTICE", "NOTICE[.\\-]*",
+		"PATENTS", "PATENTS[.\\-]*",
+		"UNLICEN[CS]E", "UNLICEN[CS]E[.\\-]*",
 		// GPL (gpl.txt, etc.)
-		"agpl[.-]*",
-		"gpl[.-]*",
-		"lgpl[.-]*",
+		"agpl[.\\-]*",
+		"gpl[.\\-]*",
+		"lgpl[.\\-]*",
 		// Other license-specific (APACHE-2.0.txt, etc.)
 		"AGPL-*[0-9]*",
 		"APACHE-*[0-9]*",
@@ -0,0 +1,225 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Number of bytes read from the start of the file to detect its content.
+const sniffSize = 8192
+
+// Content - Content information detected from the first bytes of a file.
+type Content struct {
+	// Interpreter from the shebang line without the path or version, like python or bash.
+	// Empty when there is no shebang.
+	Interpreter string
+	// Format detected from the magic bytes, like elf, gzip, zip, png or pdf.
+	// Empty when unknown.
+	Format string
+	// Binary is set for binary formats and for content with NUL bytes or mostly invalid UTF-8.
+	Binary bool
+}
+
+// magic - Magic bytes at a given offset identifying a file format.
+type magic struct {
+	offset int
+	bytes  string
+	format string
+	binary bool
+}
+
+var magicList = []magic{
+	{0, "\x7fELF", "elf", true},
+	{0, "\xfe\xed\xfa\xce", "macho", true},
+	{0, "\xfe\xed\xfa\xcf", "macho", true},
+	{0, "\xce\xfa\xed\xfe", "macho", true},
+	{0, "\xcf\xfa\xed\xfe", "macho", true},
+	{0, "\xca\xfe\xba\xbe", "class", true},
+	{0, "\x00asm", "wasm", true},
+	{0, "\x1f\x8b", "gzip", true},
+	{0, "BZh", "bzip2", true},
+	{0, "\xfd7zXZ\x00", "xz", true},
+	{0, "\x28\xb5\x2f\xfd", "zstd", true},
+	{0, "7z\xbc\xaf\x27\x1c", "7z", true},
+	{0, "PK\x03\x04", "zip", true},
+	{0, "PK\x05\x06", "zip", true},
+	{257, "ustar", "tar", true},
+	{0, "\x89PNG\r\n\x1a\n", "png", true},
+	{0, "\xff\xd8\xff", "jpeg", true},
+	{0, "GIF87a", "gif", true},
+	{0, "GIF89a", "gif", true},
+	{0, "%PDF-", "pdf", true},
+	{0, "SQLite format 3\x00", "sqlite", true},
+	{0, "\xef\xbb\xbf", "utf-8-bom", false},
+}
+
+// interpreterTypes - Types of the files run by the shebang interpreters.
+var interpreterTypes = map[string][]string{
+	"awk":     {"awk"},
+	"bash":    {"sh"},
+	"dash":    {"sh"},
+	"elixir":  {"elixir"},
+	"escript": {"erlang"},
+	"fish":    {"fish"},
+	"gawk":    {"awk"},
+	"julia":   {"julia"},
+	"ksh":     {"sh"},
+	"lua":     {"lua"},
+	"node":    {"js"},
+	"nodejs":  {"js"},
+	"perl":    {"perl"},
+	"php":     {"php"},
+	"pwsh":    {"ps"},
+	"python":  {"py"},
+	"Rscript": {"r"},
+	"ruby":    {"ruby"},
+	"sh":      {"sh"},
+	"tclsh":   {"tcl"},
+	"zsh":     {"zsh", "sh"},
+}
+
+// DetectFile - Detects the content of the file by reading its first bytes.
+func DetectFile(path string) (Content, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return Content{}, fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer fh.Close()
+	buf := make([]byte, sniffSize)
+	n, err := io.ReadFull(fh, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Content{}, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	return DetectContent(buf[:n]), nil
+}
+
+// DetectContent - Detects the content from the first bytes of a file.
+// The shebang interpreter is checked first, then the magic bytes and finally the content is checked for NUL bytes and invalid UTF-8.
+func DetectContent(b []byte) Content {
+	if bytes.HasPrefix(b, []byte("#!")) {
+		return Content{Interpreter: shebangInterpreter(b)}
+	}
+	for _, m := range magicList {
+		if len(b) >= m.offset+len(m.bytes) && string(b[m.offset:m.offset+len(m.bytes)]) == m.bytes {
+			return Content{Format: m.format, Binary: m.binary}
+		}
+	}
+	return Content{Binary: looksBinary(b)}
+}
+
+// shebangInterpreter - Returns the interpreter from the shebang line without the path or version.
+// For /usr/bin/env the first argument that is not an option or a variable assignment is used.
+func shebangInterpreter(b []byte) string {
+	line := string(b[2:])
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if strings.HasPrefix(f, "-") || strings.Contains(f, "=") {
+				continue
+			}
+			interpreter = filepath.Base(f)
+			break
+		}
+	}
+	// python3.11 -> python
+	return strings.TrimRight(interpreter, "0123456789.")
+}
+
+// looksBinary - Binary content has NUL bytes or more than 10% of invalid UTF-8 or control characters.
+// A rune cut at the end of the sample is not counted.
+func looksBinary(b []byte) bool {
+	if bytes.IndexByte(b, 0) >= 0 {
+		return true
+	}
+	suspicious := 0
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size <= 1 {
+			if !utf8.FullRune(b[i:]) {
+				break
+			}
+			suspicious++
+		} else if r < 0x20 && !strings.ContainsRune("\t\n\r\f\v\b\x1b", r) {
+			suspicious++
+		}
+		i += size
+	}
+	return suspicious*10 > len(b)
+}
+
+// InterpreterTypes - Returns the types of the files run by the interpreter.
+func InterpreterTypes(interpreter string) []string {
+	return interpreterTypes[interpreter]
+}
+
+// matchInterpreterToTypeList - Returns true if the interpreter runs any of the types.
+func matchInterpreterToTypeList(interpreter string, typeList []string) bool {
+	for _, t := range InterpreterTypes(interpreter) {
+		for _, fileType := range typeList {
+			if typeName(fileType) == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsBinaryFile - Returns true if the file content is detected as binary.
+// Only regular files are read, reading FIFOs, sockets or devices can block.
+// Files that can't be read are not considered binary.
+func IsBinaryFile(path string) bool {
+	if !isRegularFile(path) {
+		return false
+	}
+	c, err := DetectFile(path)
+	if err != nil {
+		Logger.Printf("%s", err)
+		return false
+	}
+	return c.Binary
+}
+
+// TextPredicate - Matches regular files with text content.
+func TextPredicate(fe *FileError) bool {
+	if !fe.FileInfo.Mode().IsRegular() {
+		return false
+	}
+	c, err := DetectFile(fe.Path)
+	return err == nil && !c.Binary
+}
+
+// BinaryPredicate - Matches regular files with binary content.
+func BinaryPredicate(fe *FileError) bool {
+	if !fe.FileInfo.Mode().IsRegular() {
+		return false
+	}
+	c, err := DetectFile(fe.Path)
+	return err == nil && c.Binary
+}
+
+// isRegularFile - Returns true if the path, or the file it links to, is a regular file.
+func isRegularFile(path string) bool {
+	fInfo, err := os.Stat(path)
+	return err == nil && fInfo.Mode().IsRegular()
+}
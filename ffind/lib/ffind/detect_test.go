@@ -0,0 +1,96 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectContent(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected Content
+	}{
+		{"empty", "", Content{}},
+		{"text", "hello world\n", Content{}},
+		{"utf-8", "ñandú 日本語\n", Content{}},
+		{"utf-8 cut", "abc" + "日本語"[:4], Content{}},
+		{"latin-1", "a long line of text with a single latin-1 \xf1 char\n", Content{}},
+		{"env", "#!/usr/bin/env python3\nprint()\n", Content{Interpreter: "python"}},
+		{"env options", "#!/usr/bin/env -S PYTHONPATH=. python3.11 -u\n", Content{Interpreter: "python"}},
+		{"path", "#! /bin/bash -e\n", Content{Interpreter: "bash"}},
+		{"empty shebang", "#!\n", Content{}},
+		{"elf", "\x7fELF\x02\x01\x01", Content{Format: "elf", Binary: true}},
+		{"gzip", "\x1f\x8b\x08\x00", Content{Format: "gzip", Binary: true}},
+		{"zip", "PK\x03\x04\x14\x00", Content{Format: "zip", Binary: true}},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00", Content{Format: "png", Binary: true}},
+		{"pdf", "%PDF-1.7\n", Content{Format: "pdf", Binary: true}},
+		{"tar", strings.Repeat("a", 257) + "ustar\x0000", Content{Format: "tar", Binary: true}},
+		{"bom", "\xef\xbb\xbfhello", Content{Format: "utf-8-bom"}},
+		{"nul", "text\x00text", Content{Binary: true}},
+		{"invalid utf-8", "\xff\xfe\xfa\xfb\xc0abc", Content{Binary: true}},
+		{"control", "\x01\x02\x03abc", Content{Binary: true}},
+	}
+	for _, c := range cases {
+		got := DetectContent([]byte(c.content))
+		if got != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestContentMatch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"deploy":     "#!/usr/bin/env python3\n",
+		"run":        "#!/bin/bash\n",
+		"main.py":    "print()\n",
+		"notes.txt":  "#!/usr/bin/env python3\n",
+		"README":     "text\n",
+		"image.png":  "\x89PNG\r\n\x1a\n\x00\x00",
+		"program":    "\x7fELF\x02\x01\x01\x00",
+		"empty-file": "",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func(fm FileMatcher, predicates ...Predicate) []string {
+		t.Helper()
+		w := &Walker{Matcher: fm, Predicates: predicates, Ordered: true}
+		results := []string{}
+		for e := range w.Walk(dir) {
+			if e.Error != nil {
+				t.Fatalf("unexpected error: %s", e.Error)
+			}
+			results = append(results, filepath.Base(e.Path))
+		}
+		return results
+	}
+
+	compareTestStringSlices(t, []string{"deploy", "main.py"}, list(&BasicFileMatch{MatchFileTypeList: []string{"python"}, DetectContent: true}))
+	compareTestStringSlices(t, []string{"main.py"}, list(&BasicFileMatch{MatchFileTypeList: []string{"python"}}))
+	compareTestStringSlices(t, []string{"README", "empty-file", "image.png", "notes.txt", "program"},
+		list(&BasicFileMatch{IgnoreFileTypeList: []string{"py", "sh"}, DetectContent: true}))
+	// The ignore file matcher forwards the content matching
+	compareTestStringSlices(t, []string{"deploy", "main.py"}, list(NewIgnoreFileMatch(&BasicFileMatch{MatchFileTypeList: []string{"py"}, DetectContent: true})))
+
+	compareTestStringSlices(t, []string{"README", "deploy", "empty-file", "main.py", "notes.txt", "run"}, list(&BasicFileMatch{}, TextPredicate))
+	compareTestStringSlices(t, []string{"image.png", "program"}, list(&BasicFileMatch{}, BinaryPredicate))
+
+	if !IsBinaryFile(filepath.Join(dir, "program")) || IsBinaryFile(filepath.Join(dir, "deploy")) || IsBinaryFile(filepath.Join(dir, "missing")) {
+		t.Errorf("unexpected IsBinaryFile result")
+	}
+}
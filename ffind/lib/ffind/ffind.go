@@ -36,10 +36,17 @@ For example: '.rb' and '.erb' for ruby files.
 package ffind
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
+// ErrLoop - Returned in the FileError of a dir that is the same as one of the dirs it was listed from.
+// Only possible when following symlinks.
+// The loop is reported even when the FileMatcher skips dir results, it is an error to report and not a result.
+var ErrLoop = errors.New("file system loop detected")
+
 // FileError - Struct containing the File and Error information.
 type FileError struct {
 	FileInfo os.FileInfo
@@ -107,68 +114,78 @@ func listOneLevel(
 	fe *FileError,
 	follow bool,
 	sortFn SortFn) <-chan FileError {
-	fInfo := fe.FileInfo
-	file := fe.Path
-	Logger.Printf("file: %s\n", file)
 	c := make(chan FileError)
 	go func() {
-		// Check for error
-		if fe.Error != nil {
-			Logger.Printf("listOneLevel entry error: %s", fe.Error.Error())
-			c <- *fe
-			close(c)
-			return
-		}
-		// Check if file is symlink.
-		nfe := fe
-		if fe.IsSymlink() && follow {
-			Logger.Printf("\tIsSymlink: %s", file)
-			eval, err := filepath.EvalSymlinks(fe.Path)
-			if err != nil {
-				Logger.Printf("EvalSymlinks error: %s", err)
-				// TODO: Clean up error description
-				fe.Error = err
-				c <- *fe
-				close(c)
-				return
-			}
-			nfe, err = NewFileError(eval)
-			// TODO: Figure out how to add a test for this!
-			if err != nil {
-				Logger.Printf("NewFileError error: %s", err)
-				fe.Error = err
-				c <- *fe
-				close(c)
-				return
-			}
-			Logger.Printf("\tSymlink: %s", nfe.Path)
-		}
-		if nfe.FileInfo.IsDir() {
-			Logger.Printf("\tDir: %s\n", fInfo.Name())
-			fileMatches, err := ReadDirNoSort(file)
-			if err != nil {
-				c <- FileError{fInfo, filepath.Join(filepath.Dir(file), fInfo.Name()), err}
-				close(c)
-				return
-			}
-			sortFn(fileMatches)
-			for _, fm := range fileMatches {
-				c <- FileError{fm, filepath.Join(filepath.Clean(file), fm.Name()), err}
-				Logger.Printf("\tFile: %s\n", fm.Name())
-			}
-			close(c)
-			return
+		for _, e := range readOneLevel(fe, follow, sortFn) {
+			c <- e
 		}
-		// If file is a regular file return the file and update the path to be the
-		// dirname of the file in case of resolved symlinks.
-		dirname := filepath.Dir(file)
-		c <- FileError{fInfo, filepath.Join(dirname, fInfo.Name()), nil}
 		close(c)
-		return
 	}()
 	return c
 }
 
+// readOneLevel - Same as listOneLevel but returns a slice.
+func readOneLevel(fe *FileError, follow bool, sortFn SortFn) []FileError {
+	fInfo := fe.FileInfo
+	file := fe.Path
+	Logger.Printf("file: %s\n", file)
+	// Check for error
+	if fe.Error != nil {
+		Logger.Printf("listOneLevel entry error: %s", fe.Error.Error())
+		return []FileError{*fe}
+	}
+	// Check if file is symlink.
+	nfe := fe
+	if fe.IsSymlink() && follow {
+		Logger.Printf("\tIsSymlink: %s", file)
+		eval, err := filepath.EvalSymlinks(fe.Path)
+		if err != nil {
+			Logger.Printf("EvalSymlinks error: %s", err)
+			// TODO: Clean up error description
+			fe.Error = err
+			return []FileError{*fe}
+		}
+		nfe, err = NewFileError(eval)
+		// TODO: Figure out how to add a test for this!
+		if err != nil {
+			Logger.Printf("NewFileError error: %s", err)
+			fe.Error = err
+			return []FileError{*fe}
+		}
+		Logger.Printf("\tSymlink: %s", nfe.Path)
+	}
+	if nfe.FileInfo.IsDir() {
+		Logger.Printf("\tDir: %s\n", fInfo.Name())
+		fileMatches, err := ReadDirNoSort(file)
+		if err != nil {
+			return []FileError{{fInfo, filepath.Join(filepath.Dir(file), fInfo.Name()), err}}
+		}
+		sortFn(fileMatches)
+		list := make([]FileError, 0, len(fileMatches))
+		for _, fm := range fileMatches {
+			list = append(list, FileError{fm, filepath.Join(filepath.Clean(file), fm.Name()), err})
+			Logger.Printf("\tFile: %s\n", fm.Name())
+		}
+		return list
+	}
+	// If file is a regular file return the file and update the path to be the
+	// dirname of the file in case of resolved symlinks.
+	dirname := filepath.Dir(file)
+	return []FileError{{fInfo, filepath.Join(dirname, fInfo.Name()), nil}}
+}
+
+// isDir - Determine if FileError is describing a dir, or a symlink to a dir when following symlinks.
+func isDir(fe *FileError, follow bool) bool {
+	if fe.FileInfo.IsDir() {
+		return true
+	}
+	if follow && fe.IsSymlink() {
+		fInfo, err := os.Stat(fe.Path)
+		return err == nil && fInfo.IsDir()
+	}
+	return false
+}
+
 // ListRecursive - will return a recursive list of FileError results under `path`.
 func ListRecursive(path string,
 	follow bool,
@@ -185,75 +202,166 @@ func ListRecursive(path string,
 func listRecursive(fe *FileError, follow bool, s FileMatcher, sortFn SortFn) <-chan FileError {
 	c := make(chan FileError)
 	go func() {
-		if fe.Error != nil {
-			Logger.Printf("\tError received: %s", fe.Error)
-			c <- *fe
-			close(c)
-			return
+		walkRecursive(fe, follow, s, sortFn, rootDirID(fe, follow), c)
+		close(c)
+	}()
+	return c
+}
+
+func walkRecursive(fe *FileError, follow bool, s FileMatcher, sortFn SortFn, id *dirID, c chan<- FileError) {
+	for _, e := range listDir(fe, follow, s, sortFn, id) {
+		if e.emit {
+			c <- e.FileError
 		}
-		Logger.Printf("Query: %s", fe.Path)
-		ch := listOneLevel(fe, follow, sortFn)
-		for e := range ch {
-			Logger.Printf("\tReceived: %s", e.FileInfo.Name())
-			if e.Error != nil {
-				Logger.Printf("\tError received: %s", e.Error)
-				c <- e
-				continue
-			}
+		if e.recurse {
+			Logger.Printf("Recurse: %s", e.Path)
+			e := e
+			walkRecursive(&e.FileError, follow, e.matcher, sortFn, e.id, c)
+		}
+	}
+}
 
-			// Check if file is symlink.
-			ne := &e
-			checkSymlink := func() {
-				if e.IsSymlink() && follow {
-					Logger.Printf("\tIsSymlink: %s", e.Path)
-					eval, err := filepath.EvalSymlinks(e.Path)
-					if err != nil {
-						Logger.Printf("\tEvalSymlinks error: %s", err)
-						// If the link is broken then just return the original file
-						if os.IsNotExist(err) {
-							return
-						}
-						e.Error = err
-						return
-					}
-					ne, err = NewFileError(eval)
-					if err != nil {
-						Logger.Printf("\tNew Error received: %s", err)
-						e.Error = err
+// dirID - Identifies a dir by device and inode.
+// It links to the dir it was listed from to detect loops.
+type dirID struct {
+	dev    uint64
+	ino    uint64
+	path   string
+	parent *dirID
+}
+
+// newDirID - Returns the id of the dir listed from parent, nil when the file id is not supported.
+func newDirID(fInfo os.FileInfo, path string, parent *dirID) *dirID {
+	dev, ino, ok := fileID(fInfo)
+	if !ok {
+		return nil
+	}
+	return &dirID{dev: dev, ino: ino, path: path, parent: parent}
+}
+
+// rootDirID - Returns the id of the dir the listing starts from.
+func rootDirID(fe *FileError, follow bool) *dirID {
+	if fe.Error != nil {
+		return nil
+	}
+	fInfo := fe.FileInfo
+	if follow && fe.IsSymlink() {
+		var err error
+		fInfo, err = os.Stat(fe.Path)
+		if err != nil {
+			return nil
+		}
+	}
+	return newDirID(fInfo, fe.Path, nil)
+}
+
+// ancestor - Returns the dir the id was listed from that is the same dir as id, nil if there is none.
+func (id *dirID) ancestor() *dirID {
+	if id == nil {
+		return nil
+	}
+	for a := id.parent; a != nil; a = a.parent {
+		if a.dev == id.dev && a.ino == id.ino {
+			return a
+		}
+	}
+	return nil
+}
+
+// dirEntry - An entry listed by listDir.
+type dirEntry struct {
+	FileError
+	// Send the entry to the results
+	emit bool
+	// Entry is a dir to recurse into using matcher
+	recurse bool
+	matcher FileMatcher
+	// Id of the dir to recurse into
+	id *dirID
+}
+
+// listDir - Lists one level under `fe` applying the FileMatcher.
+// Returns the entries to emit and the dirs to recurse into in listing order.
+// id is the id of `fe`, dirs that are the same as `fe` or the dirs it was listed from are returned with an ErrLoop error.
+func listDir(fe *FileError, follow bool, s FileMatcher, sortFn SortFn, id *dirID) []dirEntry {
+	if fe.Error != nil {
+		Logger.Printf("\tError received: %s", fe.Error)
+		return []dirEntry{{FileError: *fe, emit: true}}
+	}
+	Logger.Printf("Query: %s", fe.Path)
+	// Path matchers load their per dir state, like ignore files, before listing the dir.
+	var pm PathMatcher
+	if m, ok := s.(PathMatcher); ok && isDir(fe, follow) {
+		pm = m.Descend(fe.Path)
+		s = pm
+	}
+	entries := []dirEntry{}
+	for _, e := range readOneLevel(fe, follow, sortFn) {
+		Logger.Printf("\tReceived: %s", e.FileInfo.Name())
+		if e.Error != nil {
+			Logger.Printf("\tError received: %s", e.Error)
+			entries = append(entries, dirEntry{FileError: e, emit: true})
+			continue
+		}
+
+		// Check if file is symlink.
+		ne := &e
+		checkSymlink := func() {
+			if e.IsSymlink() && follow {
+				Logger.Printf("\tIsSymlink: %s", e.Path)
+				eval, err := filepath.EvalSymlinks(e.Path)
+				if err != nil {
+					Logger.Printf("\tEvalSymlinks error: %s", err)
+					// If the link is broken then just return the original file
+					if os.IsNotExist(err) {
 						return
 					}
-					Logger.Printf("\tSymlink: %s", ne.Path)
+					e.Error = err
+					return
+				}
+				ne, err = NewFileError(eval)
+				if err != nil {
+					Logger.Printf("\tNew Error received: %s", err)
+					e.Error = err
+					return
 				}
+				Logger.Printf("\tSymlink: %s", ne.Path)
 			}
-			checkSymlink()
+		}
+		checkSymlink()
 
-			if ne.FileInfo.IsDir() {
-				// TODO: Make sure to test SkipDirName
-				if s.SkipDirName(e.FileInfo.Name()) {
-					continue
-				}
-				if !s.SkipDirResults() {
-					Logger.Printf("DIR: %s - %s", e.Path, ne.Path)
-					c <- e
-				}
-				cr := listRecursive(&e, follow, s, sortFn)
-				for e := range cr {
-					Logger.Printf("Recurse: %s", e.Path)
-					c <- e
-				}
-			} else {
-				// TODO: Make sure to test SkipFileName
-				if s.SkipFileResults() || s.SkipFileName(e.FileInfo.Name()) {
-					continue
-				}
-				Logger.Printf("Else: %s", e.Path)
-				if s.MatchFileName(e.FileInfo.Name()) {
-					c <- e
-				}
+		if pm != nil && pm.SkipEntry(e.FileInfo.Name(), ne.FileInfo.IsDir()) {
+			continue
+		}
+
+		if ne.FileInfo.IsDir() {
+			// TODO: Make sure to test SkipDirName
+			if s.SkipDirName(e.FileInfo.Name()) {
+				continue
+			}
+			Logger.Printf("DIR: %s - %s", e.Path, ne.Path)
+			childID := newDirID(ne.FileInfo, e.Path, id)
+			if a := childID.ancestor(); a != nil {
+				Logger.Printf("\tLoop: %s - %s", e.Path, a.path)
+				e.Error = fmt.Errorf("%w, same dir as '%s'", ErrLoop, a.path)
+				entries = append(entries, dirEntry{FileError: e, emit: true})
+				continue
+			}
+			entries = append(entries, dirEntry{FileError: e, emit: !s.SkipDirResults(), recurse: true, matcher: s, id: childID})
+		} else {
+			// TODO: Make sure to test SkipFileName
+			if s.SkipFileResults() || s.SkipFileName(e.FileInfo.Name()) {
+				continue
+			}
+			cm, isContentMatcher := s.(ContentMatcher)
+			if isContentMatcher && cm.SkipFileContent(e.Path) {
+				continue
+			}
+			Logger.Printf("Else: %s", e.Path)
+			if s.MatchFileName(e.FileInfo.Name()) || (isContentMatcher && cm.MatchFileContent(e.Path)) {
+				entries = append(entries, dirEntry{FileError: e, emit: true})
 			}
 		}
-		close(c)
-		return
-	}()
-	return c
+	}
+	return entries
 }
@@ -0,0 +1,26 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+//go:build !windows
+// +build !windows
+
+package ffind
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID - Returns the device and inode of the file.
+func fileID(fInfo os.FileInfo) (uint64, uint64, bool) {
+	st, ok := fInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(st.Dev), uint64(st.Ino), true
+}
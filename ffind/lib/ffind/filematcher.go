@@ -9,6 +9,7 @@
 package ffind
 
 import (
+	"path/filepath"
 	"strings"
 )
 
@@ -28,6 +29,17 @@ type FileMatcher interface {
 	MatchFileName(name string) bool
 }
 
+// ContentMatcher - FileMatcher that can also match files by their content.
+//
+// listDir checks SkipFileContent after the name based skips and MatchFileContent for files that don't match by name.
+type ContentMatcher interface {
+	FileMatcher
+	// Skip file based on its content.
+	SkipFileContent(path string) bool
+	// Include file based on its content.
+	MatchFileContent(path string) bool
+}
+
 // BasicFileMatch - A simple FileMatcher interface implementation.
 type BasicFileMatch struct {
 	// Ignore files from output.
@@ -60,10 +72,13 @@ type BasicFileMatch struct {
 	// Matches files that end in any of the case insensitive provided strings.
 	// Dot is not included by default, so it must be provided in the list.
 	MatchFileExtensionList []string
-	// Ignores files that end in any of the extensions listed in the provided type.
+	// Ignores files that match any of the globs of the provided types.
 	IgnoreFileTypeList []string
-	// Matches files that end in any of the extensions listed in the provided type.
+	// Matches files that match any of the globs of the provided types.
 	MatchFileTypeList []string
+	// Match files without an extension to the type lists by their shebang interpreter.
+	// For example, a bin/deploy script with a python shebang is of type py.
+	DetectContent bool
 }
 
 // nameInEqualsList - Case Insensitive equals matching.
@@ -134,6 +149,12 @@ func nameIsHidden(name string, ignoreHidden bool) bool {
 	return false
 }
 
+// ignoreHidden - Showing VCS dirs also shows hidden files.
+// The matcher is not modified since it can be shared by concurrent walkers.
+func (l *BasicFileMatch) ignoreHidden() bool {
+	return l.IgnoreHidden && l.IgnoreVCSDirs
+}
+
 // SkipDirName - Skip Dir based on Name
 func (l *BasicFileMatch) SkipDirName(name string) bool {
 	if l.IgnoreVCSDirs {
@@ -141,12 +162,9 @@ func (l *BasicFileMatch) SkipDirName(name string) bool {
 		if nameInEqualsList(name, vcsList) {
 			return true
 		}
-	} else {
-		// TODO: Add test to catch when this is not overriden to false
-		l.IgnoreHidden = false
 	}
 	switch {
-	case nameIsHidden(name, l.IgnoreHidden):
+	case nameIsHidden(name, l.ignoreHidden()):
 		return true
 	case nameInEqualsListCase(name, l.IgnoreDirEqualsListCase):
 		return true
@@ -171,7 +189,7 @@ func (l *BasicFileMatch) SkipDirResults() bool {
 // SkipFileName - Skip File based on Name
 func (l *BasicFileMatch) SkipFileName(name string) bool {
 	switch {
-	case nameIsHidden(name, l.IgnoreHidden):
+	case nameIsHidden(name, l.ignoreHidden()):
 		return true
 	case nameInEqualsListCase(name, l.IgnoreFileEqualsListCase):
 		return true
@@ -194,22 +212,11 @@ func (l *BasicFileMatch) SkipFileResults() bool {
 	return l.IgnoreFileResults
 }
 
+// matchFileToTypeList - Case insensitive glob matching against the globs of the types.
 func matchFileToTypeList(name string, typeList []string) bool {
 	for _, fileType := range typeList {
-		Logger.Printf("fileType %s\n", fileType)
-		// Match against full filename first
-		if filenameList, ok := typeListFiles[fileType]; ok {
-			Logger.Printf("filenameList: %v\n", filenameList)
-			if nameInEqualsListCase(name, filenameList) {
-				return true
-			}
-		}
-		// Match against ext
-		if extList, ok := typeListExt[fileType]; ok {
-			Logger.Printf("extList: %v\n", extList)
-			if nameInExtensionList(name, extList) {
-				return true
-			}
+		if matchTypeGlobs(name, fileType) {
+			return true
 		}
 	}
 	return false
@@ -231,3 +238,38 @@ func (l *BasicFileMatch) MatchFileName(name string) bool {
 	}
 	return false
 }
+
+// hasExtension - Returns true if the name has an extension, names starting with . don't count.
+func hasExtension(name string) bool {
+	return strings.Contains(strings.TrimLeft(name, "."), ".")
+}
+
+// contentInterpreter - Returns the shebang interpreter of the file when content detection applies to it.
+// Only regular files are read, reading FIFOs, sockets or devices can block.
+func (l *BasicFileMatch) contentInterpreter(path string) string {
+	if !l.DetectContent || hasExtension(filepath.Base(path)) || !isRegularFile(path) {
+		return ""
+	}
+	c, err := DetectFile(path)
+	if err != nil {
+		Logger.Printf("%s", err)
+		return ""
+	}
+	return c.Interpreter
+}
+
+// SkipFileContent - Skip File based on its shebang interpreter
+func (l *BasicFileMatch) SkipFileContent(path string) bool {
+	if len(l.IgnoreFileTypeList) == 0 {
+		return false
+	}
+	return matchInterpreterToTypeList(l.contentInterpreter(path), l.IgnoreFileTypeList)
+}
+
+// MatchFileContent - Match File based on its shebang interpreter
+func (l *BasicFileMatch) MatchFileContent(path string) bool {
+	if len(l.MatchFileTypeList) == 0 {
+		return false
+	}
+	return matchInterpreterToTypeList(l.contentInterpreter(path), l.MatchFileTypeList)
+}
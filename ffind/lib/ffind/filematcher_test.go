@@ -35,6 +35,14 @@ func TestBasicFileMatcher(t *testing.T) {
 		if bfm.SkipFileName(".file") {
 			t.Fatal("Skipped wrong file")
 		}
+		// Showing VCS dirs shows hidden files
+		bfm = BasicFileMatch{IgnoreHidden: true, IgnoreVCSDirs: false}
+		if bfm.SkipFileName(".file") {
+			t.Fatal("Skipped wrong file")
+		}
+		if bfm.SkipDirName(".git") {
+			t.Fatal("Skipped wrong file")
+		}
 	})
 
 	// log.SetOutput(os.Stderr)
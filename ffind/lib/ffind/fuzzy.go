@@ -0,0 +1,199 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Fuzzy scoring based on fzf's algorithm, https://github.com/junegunn/fzf/blob/master/src/algo/algo.go
+// Licensed under the MIT.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+	// Match after a non word char like _ - . or space
+	bonusBoundary = scoreMatch / 2
+	// Match after a / path separator
+	bonusBoundaryDelimiter = bonusBoundary + 1
+	// Match of a non word char
+	bonusNonWord = scoreMatch / 2
+	// Match of an upper case char after a lower case char or a number after a non number
+	bonusCamel123 = bonusBoundary + scoreGapExtension
+	// Minimum bonus of consecutive matches, so they are preferred over a gap
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+	// The bonus of the first char of the pattern is multiplied
+	bonusFirstCharMultiplier = 2
+)
+
+type charClass int
+
+const (
+	charNonWord charClass = iota
+	charDelimiter
+	charLower
+	charUpper
+	charLetter
+	charNumber
+)
+
+func classOf(r rune) charClass {
+	switch {
+	case r == '/' || r == '\\':
+		return charDelimiter
+	case r >= 'a' && r <= 'z':
+		return charLower
+	case r >= 'A' && r <= 'Z':
+		return charUpper
+	case r >= '0' && r <= '9':
+		return charNumber
+	case unicode.IsLower(r):
+		return charLower
+	case unicode.IsUpper(r):
+		return charUpper
+	case unicode.IsLetter(r):
+		return charLetter
+	case unicode.IsNumber(r):
+		return charNumber
+	}
+	return charNonWord
+}
+
+// positionBonus - Bonus of matching a char of class after a char of class prev.
+func positionBonus(prev, class charClass) int {
+	switch {
+	case class == charNonWord || class == charDelimiter:
+		return bonusNonWord
+	case prev == charDelimiter:
+		return bonusBoundaryDelimiter
+	case prev == charNonWord:
+		return bonusBoundary
+	case prev == charLower && class == charUpper, prev != charNumber && class == charNumber:
+		return bonusCamel123
+	}
+	return 0
+}
+
+// FuzzyMatch - Returns the score of the best match of pattern as a subsequence of path.
+// Returns false if path doesn't contain all the chars of pattern in order.
+//
+// Matches score higher when the chars are consecutive and when they are at the start of path segments or words.
+// Gaps between the matched chars are penalized.
+func FuzzyMatch(pattern, path string, caseSensitive bool) (int, bool) {
+	p := []rune(pattern)
+	t := []rune(path)
+	if len(p) == 0 {
+		return 0, true
+	}
+	if !caseSensitive {
+		for i := range p {
+			p[i] = unicode.ToLower(p[i])
+		}
+	}
+	n, m := len(p), len(t)
+	if n > m {
+		return 0, false
+	}
+
+	bonus := make([]int, m)
+	match := make([]rune, m)
+	prev := charDelimiter
+	for j, r := range t {
+		class := classOf(r)
+		bonus[j] = positionBonus(prev, class)
+		prev = class
+		if !caseSensitive {
+			r = unicode.ToLower(r)
+		}
+		match[j] = r
+	}
+
+	// score[i][j] - Best score with p[i] matched at t[j], noMatch if not possible.
+	// runBonus[i][j] - Bonus carried along the consecutive run ending at t[j].
+	const noMatch = -1 << 30
+	score := make([][]int, n)
+	runBonus := make([][]int, n)
+	for i := range score {
+		score[i] = make([]int, m)
+		runBonus[i] = make([]int, m)
+		for j := range score[i] {
+			score[i][j] = noMatch
+		}
+	}
+	for i := 0; i < n; i++ {
+		// Best score of matching p[i-1] before t[j-1] plus the gap up to t[j]
+		gap := noMatch
+		for j := i; j < m; j++ {
+			if i > 0 && j >= 2 {
+				gap = maxInt(gap+scoreGapExtension, score[i-1][j-2]+scoreGapStart)
+			}
+			if match[j] != p[i] {
+				continue
+			}
+			if i == 0 {
+				score[i][j] = scoreMatch + bonus[j]*bonusFirstCharMultiplier
+				runBonus[i][j] = bonus[j]
+				continue
+			}
+			best := noMatch
+			if gap > noMatch/2 {
+				best = gap + scoreMatch + bonus[j]
+				runBonus[i][j] = bonus[j]
+			}
+			if s := score[i-1][j-1]; s > noMatch/2 {
+				b := maxInt(bonus[j], maxInt(runBonus[i-1][j-1], bonusConsecutive))
+				if s+scoreMatch+b >= best {
+					best = s + scoreMatch + b
+					runBonus[i][j] = b
+				}
+			}
+			score[i][j] = best
+		}
+	}
+
+	best := noMatch
+	for _, s := range score[n-1] {
+		best = maxInt(best, s)
+	}
+	if best <= noMatch/2 {
+		return 0, false
+	}
+	return best, true
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// FuzzyResult - A result matched with FuzzyMatch.
+type FuzzyResult struct {
+	FileError
+	Score int
+	// Path the pattern was matched against
+	Match string
+}
+
+// SortFuzzyResults - Sorts the results by decreasing score.
+// Ties are sorted by shorter match path first and then by name.
+func SortFuzzyResults(results []FuzzyResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Match) != len(b.Match) {
+			return len(a.Match) < len(b.Match)
+		}
+		return a.Match < b.Match
+	})
+}
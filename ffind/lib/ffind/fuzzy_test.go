@@ -0,0 +1,83 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		pattern       string
+		path          string
+		caseSensitive bool
+		match         bool
+	}{
+		{"bttfplan", "bt/terraform/plan.go", false, true},
+		{"", "any", false, true},
+		{"btplan", "bt/terraform/pla.go", false, false},
+		{"nalp", "plan", false, false},
+		{"longer than path", "path", false, false},
+		{"TFPlan", "bt/terraform/plan.go", false, true},
+		{"TFPlan", "bt/terraform/plan.go", true, false},
+		{"ñu", "año/ñu.txt", false, true},
+	}
+	for _, c := range cases {
+		_, ok := FuzzyMatch(c.pattern, c.path, c.caseSensitive)
+		if ok != c.match {
+			t.Errorf("%s %s: expected %v, got %v", c.pattern, c.path, c.match, ok)
+		}
+	}
+
+	// Each pair is ordered from the better to the worse match
+	better := []struct {
+		pattern string
+		a, b    string
+	}{
+		// Consecutive runs
+		{"plan", "plan.go", "pxlxaxn.go"},
+		{"abc", "abc.go", "a_b_c.go"},
+		// Path segment and word starts
+		{"tp", "terraform/plan.go", "testplan.go"},
+		{"plan", "bt/plan.go", "bt/xplan.go"},
+		{"fb", "fooBar.go", "foobar.go"},
+		// Smaller gaps
+		{"ab", "axb", "axxxb"},
+	}
+	for _, c := range better {
+		sa, okA := FuzzyMatch(c.pattern, c.a, false)
+		sb, okB := FuzzyMatch(c.pattern, c.b, false)
+		if !okA || !okB || sa <= sb {
+			t.Errorf("%s: expected %s (%d) to score higher than %s (%d)", c.pattern, c.a, sa, c.b, sb)
+		}
+	}
+}
+
+func TestSortFuzzyResults(t *testing.T) {
+	paths := []string{
+		"bt/xplxaxn.go",
+		"a/very/long/path/plan.go",
+		"bt/terraform/plan.go",
+		"bt/plan/plan.go",
+	}
+	results := []FuzzyResult{}
+	for _, p := range paths {
+		score, ok := FuzzyMatch("plan", p, false)
+		if !ok {
+			t.Fatalf("%s: expected match", p)
+		}
+		results = append(results, FuzzyResult{FileError: FileError{Path: p}, Score: score, Match: p})
+	}
+	SortFuzzyResults(results)
+	sorted := []string{}
+	for _, r := range results {
+		sorted = append(sorted, r.Match)
+	}
+	compareTestStringSlices(t, []string{"bt/plan/plan.go", "bt/terraform/plan.go", "a/very/long/path/plan.go", "bt/xplxaxn.go"}, sorted)
+}
@@ -0,0 +1,375 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PathMatcher - Optional FileMatcher extension for matchers that depend on the location of the files.
+// listRecursive calls Descend before listing each dir and uses the returned matcher for its entries.
+type PathMatcher interface {
+	FileMatcher
+	// Descend - Returns the matcher for the entries of dir.
+	Descend(dir string) PathMatcher
+	// SkipEntry - Skip file or dir based on its name in the dir the matcher descended into.
+	// Dirs are not recursed into.
+	SkipEntry(name string, isDir bool) bool
+}
+
+// IgnoreFileMatch - A PathMatcher that skips the files and dirs ignored by .gitignore and .ignore files.
+//
+// Ignore files are loaded as the dirs are descended into.
+// Inside a git repository it also loads the .gitignore files of the parent dirs up to the root of the repository,
+// .git/info/exclude and the global excludes file (core.excludesFile or ~/.config/git/ignore).
+//
+// The wrapped FileMatcher, for example a BasicFileMatch, handles the name based matching.
+type IgnoreFileMatch struct {
+	FileMatcher
+	// Absolute path of the dir the matcher descended into
+	dir string
+	// Ignore rules in order of increasing precedence
+	sets []*ignoreSet
+	// Path of dir relative to the base of each set, with a trailing /
+	prefixes []string
+}
+
+// ignoreSet - The rules of an ignore file.
+type ignoreSet struct {
+	// Absolute path of the dir the patterns are relative to
+	base  string
+	rules []ignoreRule
+	// Rules from git files, they don't apply inside nested repositories
+	git bool
+}
+
+// ignoreRule - A parsed gitignore pattern.
+type ignoreRule struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// NewIgnoreFileMatch - Returns an IgnoreFileMatch wrapping m.
+func NewIgnoreFileMatch(m FileMatcher) *IgnoreFileMatch {
+	return &IgnoreFileMatch{FileMatcher: m}
+}
+
+// Descend - Returns the matcher for the entries of dir with the ignore files in dir loaded.
+func (im *IgnoreFileMatch) Descend(dir string) PathMatcher {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		Logger.Printf("IgnoreFileMatch ERROR: %s", err)
+		return im
+	}
+	n := &IgnoreFileMatch{FileMatcher: im.FileMatcher, dir: abs}
+	if im.dir == "" {
+		// First dir, load the rules from the parent dirs
+		n.sets = loadParentIgnoreSets(abs)
+	} else {
+		n.sets = im.sets
+		if isRepoRoot(abs) {
+			n.sets = repoIgnoreSets(abs, n.sets)
+		}
+		n.sets = append(n.sets[:len(n.sets):len(n.sets)], loadDirIgnoreSets(abs)...)
+	}
+	for _, set := range n.sets {
+		prefix := ""
+		if rel, err := filepath.Rel(set.base, abs); err == nil && rel != "." {
+			prefix = filepath.ToSlash(rel) + "/"
+		}
+		n.prefixes = append(n.prefixes, prefix)
+	}
+	return n
+}
+
+// SkipEntry - Skip file or dir ignored by the loaded ignore files.
+func (im *IgnoreFileMatch) SkipEntry(name string, isDir bool) bool {
+	for i := len(im.sets) - 1; i >= 0; i-- {
+		if r, ok := im.sets[i].match(im.prefixes[i]+name, isDir); ok {
+			if r.negate {
+				return false
+			}
+			Logger.Printf("Exclude %s with ignore pattern %s", filepath.Join(im.dir, name), r.pattern)
+			return true
+		}
+	}
+	return false
+}
+
+// match - Returns the last rule that matches the path relative to the set base.
+func (set *ignoreSet) match(rel string, isDir bool) (ignoreRule, bool) {
+	for i := len(set.rules) - 1; i >= 0; i-- {
+		r := set.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			return r, true
+		}
+	}
+	return ignoreRule{}, false
+}
+
+// loadParentIgnoreSets - Loads the ignore rules that apply to dir.
+// Inside a git repository, these are the global rules and the ignore files from the root of the repository down to dir.
+func loadParentIgnoreSets(dir string) []*ignoreSet {
+	dirs := []string{dir}
+	root := ""
+	for d := dir; ; {
+		if isRepoRoot(d) {
+			root = d
+			break
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+		dirs = append(dirs, d)
+	}
+	if root == "" {
+		return loadDirIgnoreSets(dir)
+	}
+	sets := []*ignoreSet{}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] == root {
+			sets = repoIgnoreSets(root, sets)
+		}
+		sets = append(sets, loadDirIgnoreSets(dirs[i])...)
+	}
+	return sets
+}
+
+// isRepoRoot - Whether dir contains a .git dir, or a .git file for worktrees and submodules.
+func isRepoRoot(dir string) bool {
+	_, err := os.Lstat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// repoIgnoreSets - Returns the rules for a new git repository at root.
+// The git rules of the parent repository don't apply, the .ignore rules are kept.
+func repoIgnoreSets(root string, parent []*ignoreSet) []*ignoreSet {
+	sets := []*ignoreSet{}
+	for _, set := range parent {
+		if !set.git {
+			sets = append(sets, set)
+		}
+	}
+	if file := globalExcludesFile(); file != "" {
+		if set := loadIgnoreFile(file, root, true); set != nil {
+			sets = append(sets, set)
+		}
+	}
+	if set := loadIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), root, true); set != nil {
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// loadDirIgnoreSets - Loads the .gitignore and .ignore files in dir, .ignore rules take precedence.
+func loadDirIgnoreSets(dir string) []*ignoreSet {
+	sets := []*ignoreSet{}
+	if set := loadIgnoreFile(filepath.Join(dir, ".gitignore"), dir, true); set != nil {
+		sets = append(sets, set)
+	}
+	if set := loadIgnoreFile(filepath.Join(dir, ".ignore"), dir, false); set != nil {
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// loadIgnoreFile - Returns the rules in file, nil if the file can't be read or has no rules.
+func loadIgnoreFile(file, base string, git bool) *ignoreSet {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer fh.Close()
+	set := &ignoreSet{base: base, git: git}
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		if r, ok := parseIgnorePattern(scanner.Text()); ok {
+			set.rules = append(set.rules, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		Logger.Printf("loadIgnoreFile ERROR: %s: %s", file, err)
+	}
+	if len(set.rules) == 0 {
+		return nil
+	}
+	Logger.Printf("Loaded %d ignore patterns from %s", len(set.rules), file)
+	return set
+}
+
+// SkipFileContent - Forwards to the wrapped matcher when it is a ContentMatcher.
+func (m *IgnoreFileMatch) SkipFileContent(path string) bool {
+	if cm, ok := m.FileMatcher.(ContentMatcher); ok {
+		return cm.SkipFileContent(path)
+	}
+	return false
+}
+
+// MatchFileContent - Forwards to the wrapped matcher when it is a ContentMatcher.
+func (m *IgnoreFileMatch) MatchFileContent(path string) bool {
+	if cm, ok := m.FileMatcher.(ContentMatcher); ok {
+		return cm.MatchFileContent(path)
+	}
+	return false
+}
+
+// globalExcludesFile - Returns the core.excludesFile from the git config, defaults to $XDG_CONFIG_HOME/git/ignore.
+func globalExcludesFile() string {
+	home, _ := os.UserHomeDir()
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" && home != "" {
+		config = filepath.Join(home, ".config")
+	}
+	file := ""
+	for _, gitConfig := range []string{filepath.Join(config, "git", "config"), filepath.Join(home, ".gitconfig")} {
+		if f := gitConfigExcludesFile(gitConfig); f != "" {
+			file = f
+		}
+	}
+	if file == "" {
+		if config == "" {
+			return ""
+		}
+		return filepath.Join(config, "git", "ignore")
+	}
+	if strings.HasPrefix(file, "~/") {
+		file = filepath.Join(home, file[2:])
+	}
+	return file
+}
+
+// gitConfigExcludesFile - Returns the excludesFile from the core section of a git config file.
+func gitConfigExcludesFile(file string) string {
+	fh, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer fh.Close()
+	value := ""
+	section := ""
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+			continue
+		}
+		if section != "core" {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i > 0 && strings.EqualFold(strings.TrimSpace(line[:i]), "excludesfile") {
+			value = strings.Trim(strings.TrimSpace(line[i+1:]), `"`)
+		}
+	}
+	return value
+}
+
+// parseIgnorePattern - Parses a gitignore line, returns false for blank lines and comments.
+//
+// Follows the gitignore(5) rules:
+//
+//   - A leading ! negates the pattern, use \! and \# for literal ! and # at the start.
+//   - A trailing / only matches dirs.
+//   - Patterns with a / at the start or in the middle are relative to the dir of the ignore file,
+//     otherwise they match at any level below it.
+//   - * and ? don't match /, ** matches any number of dirs.
+func parseIgnorePattern(line string) (ignoreRule, bool) {
+	r := ignoreRule{}
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return r, false
+	}
+	r.pattern = line
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return r, false
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/") && (i == 0 || line[i-1] == '/'):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**") && i+2 == len(line) && (i == 0 || line[i-1] == '/'):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+			for i+1 < len(line) && line[i+1] == '*' {
+				i++
+			}
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.Index(line[i+1:], "]")
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			// Allow ] as the first char of the class
+			if end == 0 || end == 1 && line[i+1] == '!' {
+				if next := strings.Index(line[i+end+2:], "]"); next >= 0 {
+					end += next + 1
+				}
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(line[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		Logger.Printf("Invalid ignore pattern %s: %s", r.pattern, err)
+		return r, false
+	}
+	r.re = re
+	return r, true
+}
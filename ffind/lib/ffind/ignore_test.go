@@ -0,0 +1,164 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnorePattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		match   bool
+	}{
+		{"*.log", "a.log", false, true},
+		{"*.log", "dir/sub/a.log", false, true},
+		{"*.log", "a.log.txt", false, false},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"build/", "src/build", true, true},
+		{"/build", "build", false, true},
+		{"/build", "src/build", false, false},
+		{"doc/*.txt", "doc/a.txt", false, true},
+		{"doc/*.txt", "doc/sub/a.txt", false, false},
+		{"doc/*.txt", "src/doc/a.txt", false, false},
+		{"**/foo", "foo", false, true},
+		{"**/foo", "a/b/foo", false, true},
+		{"**/foo/bar", "a/foo/bar", false, true},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**", "a/x/y", false, true},
+		{"a/**", "a", true, false},
+		{"fo?", "foo", false, true},
+		{"fo?", "fo/", false, false},
+		{"[abc].txt", "b.txt", false, true},
+		{"[!abc].txt", "b.txt", false, false},
+		{"[!abc].txt", "d.txt", false, true},
+		{"[a-c]*", "bar", false, true},
+		{`\#file`, "#file", false, true},
+		{`\!file`, "!file", false, true},
+		{"!keep.log", "keep.log", false, true},
+		{"trailing  ", "trailing", false, true},
+		{`space\ `, "space ", false, true},
+		{"a.b", "axb", false, false},
+	}
+	for _, c := range cases {
+		r, ok := parseIgnorePattern(c.pattern)
+		if !ok {
+			t.Fatalf("pattern '%s' not parsed", c.pattern)
+		}
+		set := &ignoreSet{rules: []ignoreRule{r}}
+		_, match := set.match(c.path, c.isDir)
+		if match != c.match {
+			t.Errorf("pattern '%s', path '%s', dir %v: got %v, expected %v", c.pattern, c.path, c.isDir, match, c.match)
+		}
+	}
+
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		if _, ok := parseIgnorePattern(line); ok {
+			t.Errorf("unexpected rule for '%s'", line)
+		}
+	}
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func listTestFiles(t *testing.T, dir string, s FileMatcher) []string {
+	t.Helper()
+	results := []string{}
+	for e := range ListRecursive(dir, true, s, SortFnByName) {
+		if e.Error != nil {
+			t.Fatalf("unexpected error: %s", e.Error)
+		}
+		rel, _ := filepath.Rel(dir, e.Path)
+		results = append(results, filepath.ToSlash(rel))
+	}
+	return results
+}
+
+func TestIgnoreFileMatch(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	writeTestFiles(t, home, map[string]string{
+		".config/git/ignore": "*.swp\n",
+	})
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".git/info/exclude":     "local.txt\n",
+		".gitignore":            "*.log\n!keep.log\nbuild/\n/root.txt\nnode_modules\n",
+		".ignore":               "secret.txt\n",
+		"a.go":                  "",
+		"a.go.swp":              "",
+		"debug.log":             "",
+		"keep.log":              "",
+		"local.txt":             "",
+		"root.txt":              "",
+		"secret.txt":            "",
+		"build/out":             "",
+		"node_modules/x/y.js":   "",
+		"src/build":             "",
+		"src/root.txt":          "",
+		"src/.gitignore":        "!debug.log\ngen/\n",
+		"src/debug.log":         "",
+		"src/gen/a.go":          "",
+		"src/lib/other.log":     "",
+		"vendor/.git":           "gitdir: ../.git/modules/vendor\n",
+		"vendor/debug.log":      "",
+		"vendor/secret.txt":     "",
+		"vendor/sub/.gitignore": "*.go\n",
+		"vendor/sub/v.go":       "",
+	})
+
+	bfm := &BasicFileMatch{IgnoreVCSDirs: true, IgnoreHidden: true}
+	compareTestStringSlices(t, []string{
+		"a.go",
+		"keep.log",
+		"src",
+		"src/build",
+		"src/debug.log",
+		"src/lib",
+		"src/root.txt",
+		"vendor",
+		"vendor/debug.log",
+		"vendor/sub",
+	}, listTestFiles(t, dir, NewIgnoreFileMatch(bfm)))
+
+	// Starting in a subdir loads the ignore files of the parent dirs
+	compareTestStringSlices(t, []string{
+		"build",
+		"debug.log",
+		"lib",
+		"root.txt",
+	}, listTestFiles(t, filepath.Join(dir, "src"), NewIgnoreFileMatch(bfm)))
+
+	// Without the IgnoreFileMatch all files are listed
+	all := listTestFiles(t, dir, bfm)
+	if len(all) != 25 {
+		t.Errorf("unexpected results: %d, %v", len(all), all)
+	}
+}
@@ -0,0 +1,18 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"os"
+)
+
+// fileOwner - File owners are not supported on windows.
+func fileOwner(fInfo os.FileInfo) (int, int, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,256 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Predicate - Matches results based on their file info.
+// Unlike FileMatcher, dirs that don't match are still recursed into.
+type Predicate func(fe *FileError) bool
+
+// All - Predicate that matches when all the predicates match.
+func All(predicates ...Predicate) Predicate {
+	return func(fe *FileError) bool {
+		for _, p := range predicates {
+			if !p(fe) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any - Predicate that matches when any of the predicates match.
+func Any(predicates ...Predicate) Predicate {
+	return func(fe *FileError) bool {
+		for _, p := range predicates {
+			if p(fe) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not - Predicate that matches when p doesn't match.
+func Not(p Predicate) Predicate {
+	return func(fe *FileError) bool {
+		return !p(fe)
+	}
+}
+
+// compareOp - Splits the leading + or - from the expression.
+// Returns 1 for +, -1 for - and 0 when there is no sign.
+func compareOp(expr string) (int, string) {
+	switch {
+	case strings.HasPrefix(expr, "+"):
+		return 1, expr[1:]
+	case strings.HasPrefix(expr, "-"):
+		return -1, expr[1:]
+	}
+	return 0, expr
+}
+
+// compareUnits - Compares value with n units.
+// Without a sign, value rounded up to the unit must be n.
+func compareUnits(op int, value, n, unit int64) bool {
+	switch op {
+	case 1:
+		return value > n*unit
+	case -1:
+		return value < n*unit
+	}
+	return (value+unit-1)/unit == n
+}
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"c": 1,
+	"b": 1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// SizePredicate - Matches files by size, like find's -size.
+//
+// The expression is [+-]<n>[unit], the unit is one of c or b for bytes (default), k, M, G or T (powers of 1024).
+// +<n> matches larger files, -<n> smaller files and <n> files whose size rounded up to the unit is n.
+func SizePredicate(expr string) (Predicate, error) {
+	op, s := compareOp(expr)
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := sizeUnits[strings.ToLower(s[i:])]
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("invalid size '%s', expected [+-]<n>[c|k|M|G|T]", expr)
+	}
+	return func(fe *FileError) bool {
+		return compareUnits(op, fe.FileInfo.Size(), n, unit)
+	}, nil
+}
+
+var timeUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// MtimePredicate - Matches files by the time since they were last modified, relative to now.
+//
+// The expression is [+-]<n>[unit], the unit is one of s, m, h, d (default) or w.
+// -<n> matches files modified less than n units ago, +<n> more than n units ago
+// and <n> files modified between n and n+1 units ago.
+func MtimePredicate(expr string, now time.Time) (Predicate, error) {
+	op, s := compareOp(expr)
+	unit := timeUnits["d"]
+	if len(s) > 0 {
+		if u, ok := timeUnits[s[len(s)-1:]]; ok {
+			unit = u
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time '%s', expected [+-]<n>[s|m|h|d|w]", expr)
+	}
+	d := time.Duration(n) * unit
+	return func(fe *FileError) bool {
+		age := now.Sub(fe.FileInfo.ModTime())
+		switch op {
+		case 1:
+			return age > d
+		case -1:
+			return age < d
+		}
+		return age >= d && age < d+unit
+	}, nil
+}
+
+// NewerPredicate - Matches files modified more recently than file.
+func NewerPredicate(file string) (Predicate, error) {
+	fInfo, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat '%s': %w", file, err)
+	}
+	t := fInfo.ModTime()
+	return func(fe *FileError) bool {
+		return fe.FileInfo.ModTime().After(t)
+	}, nil
+}
+
+// PermPredicate - Matches files by permission bits, like find's -perm with octal modes.
+//
+// <mode> matches the exact permissions, -<mode> files with all the bits set and /<mode> files with any of the bits set.
+func PermPredicate(expr string) (Predicate, error) {
+	s := expr
+	prefix := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "/") {
+		prefix, s = s[:1], s[1:]
+	}
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 07777 {
+		return nil, fmt.Errorf("invalid permissions '%s', expected an octal mode like 644, -u+x is not supported, use -100", expr)
+	}
+	mode := fileMode(uint32(m))
+	return func(fe *FileError) bool {
+		perm := fe.FileInfo.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		switch prefix {
+		case "-":
+			return perm&mode == mode
+		case "/":
+			return mode == 0 || perm&mode != 0
+		}
+		return perm == mode
+	}, nil
+}
+
+// fileMode - Converts unix permission bits to an os.FileMode.
+func fileMode(m uint32) os.FileMode {
+	mode := os.FileMode(m & 0777)
+	if m&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// OwnerPredicate - Matches files by owner, the expression is <user>, :<group> or <user>:<group>.
+// Users and groups can be names or numeric ids.
+func OwnerPredicate(expr string) (Predicate, error) {
+	userName, groupName := expr, ""
+	if i := strings.Index(expr, ":"); i >= 0 {
+		userName, groupName = expr[:i], expr[i+1:]
+	}
+	if userName == "" && groupName == "" {
+		return nil, fmt.Errorf("invalid owner '%s', expected <user>, :<group> or <user>:<group>", expr)
+	}
+	uid, gid := -1, -1
+	if userName != "" {
+		id, err := strconv.Atoi(userName)
+		if err != nil {
+			u, err := user.Lookup(userName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find user '%s': %w", userName, err)
+			}
+			id, _ = strconv.Atoi(u.Uid)
+		}
+		uid = id
+	}
+	if groupName != "" {
+		id, err := strconv.Atoi(groupName)
+		if err != nil {
+			g, err := user.LookupGroup(groupName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find group '%s': %w", groupName, err)
+			}
+			id, _ = strconv.Atoi(g.Gid)
+		}
+		gid = id
+	}
+	return func(fe *FileError) bool {
+		fileUID, fileGID, ok := fileOwner(fe.FileInfo)
+		if !ok {
+			return false
+		}
+		return (uid < 0 || uid == fileUID) && (gid < 0 || gid == fileGID)
+	}, nil
+}
+
+// EmptyPredicate - Matches empty regular files and dirs.
+func EmptyPredicate(fe *FileError) bool {
+	if fe.FileInfo.IsDir() {
+		fh, err := os.Open(fe.Path)
+		if err != nil {
+			return false
+		}
+		defer fh.Close()
+		_, err = fh.Readdirnames(1)
+		return err == io.EOF
+	}
+	return fe.FileInfo.Mode().IsRegular() && fe.FileInfo.Size() == 0
+}
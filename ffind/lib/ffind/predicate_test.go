@@ -0,0 +1,168 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPredicates(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+		perm os.FileMode
+	}{
+		{"empty", 0, time.Hour, 0644},
+		{"small", 100, 3 * 24 * time.Hour, 0600},
+		{"large", 3 << 20, 10 * time.Minute, 0755},
+	}
+	fes := map[string]*FileError{}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		err := os.WriteFile(path, make([]byte, f.size), f.perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Chmod(path, f.perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-f.age)
+		err = os.Chtimes(path, mtime, mtime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fes[f.name], err = NewFileError(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.Mkdir(filepath.Join(dir, "emptydir"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fes["emptydir"], _ = NewFileError(filepath.Join(dir, "emptydir"))
+	fes["dir"], _ = NewFileError(dir)
+
+	matching := func(p Predicate) map[string]bool {
+		m := map[string]bool{}
+		for name, fe := range fes {
+			if p(fe) {
+				m[name] = true
+			}
+		}
+		return m
+	}
+	check := func(name string, p Predicate, err error, expected ...string) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		got := matching(p)
+		if len(got) != len(expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, got)
+			return
+		}
+		for _, e := range expected {
+			if !got[e] {
+				t.Errorf("%s: expected %v, got %v", name, expected, got)
+				return
+			}
+		}
+	}
+	isFile := func(fe *FileError) bool { return fe.FileInfo.Mode().IsRegular() }
+
+	p, err := SizePredicate("+1M")
+	check("size +1M", p, err, "large")
+	p, err = SizePredicate("-1k")
+	check("size -1k", All(p, isFile), err, "empty", "small")
+	p, err = SizePredicate("3M")
+	check("size 3M", p, err, "large")
+	p, err = SizePredicate("100c")
+	check("size 100c", p, err, "small")
+	_, err = SizePredicate("10X")
+	if err == nil {
+		t.Errorf("expected error")
+	}
+
+	p, err = MtimePredicate("-2d", now)
+	check("mtime -2d", All(p, isFile), err, "empty", "large")
+	p, err = MtimePredicate("+2d", now)
+	check("mtime +2d", p, err, "small")
+	p, err = MtimePredicate("-30m", now)
+	check("mtime -30m", All(p, isFile), err, "large")
+	p, err = MtimePredicate("3", now)
+	check("mtime 3", p, err, "small")
+	_, err = MtimePredicate("2y", now)
+	if err == nil {
+		t.Errorf("expected error")
+	}
+
+	p, err = NewerPredicate(fes["empty"].Path)
+	check("newer", All(p, isFile), err, "large")
+
+	p, err = PermPredicate("644")
+	check("perm 644", p, err, "empty")
+	p, err = PermPredicate("-100")
+	check("perm -100", All(p, isFile), err, "large")
+	p, err = PermPredicate("/044")
+	check("perm /044", All(p, isFile), err, "empty", "large")
+	_, err = PermPredicate("u+x")
+	if err == nil {
+		t.Errorf("expected error")
+	}
+
+	p, err = OwnerPredicate(strconv.Itoa(os.Getuid()))
+	check("owner", All(p, isFile), err, "empty", "small", "large")
+	p, err = OwnerPredicate(":" + strconv.Itoa(os.Getgid()+1))
+	check("owner other group", p, err)
+
+	check("empty", EmptyPredicate, nil, "empty", "emptydir")
+	check("not empty", Not(EmptyPredicate), nil, "small", "large", "dir")
+	check("any", Any(EmptyPredicate, isFile), nil, "empty", "small", "large", "emptydir")
+}
+
+func TestWalkerDepth(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a":       "",
+		"b/c":     "",
+		"b/d/e":   "",
+		"b/d/f/g": "",
+	})
+	cases := []struct {
+		min, max int
+		expected []string
+	}{
+		{0, 0, []string{"a", "b", "b/c", "b/d", "b/d/e", "b/d/f", "b/d/f/g"}},
+		{0, 1, []string{"a", "b"}},
+		{0, 2, []string{"a", "b", "b/c", "b/d"}},
+		{2, 0, []string{"b/c", "b/d", "b/d/e", "b/d/f", "b/d/f/g"}},
+		{2, 3, []string{"b/c", "b/d", "b/d/e", "b/d/f"}},
+	}
+	for _, c := range cases {
+		w := &Walker{MinDepth: c.min, MaxDepth: c.max, Ordered: true}
+		results := []string{}
+		for e := range w.Walk(dir) {
+			if e.Error != nil {
+				t.Fatalf("unexpected error: %s", e.Error)
+			}
+			rel, _ := filepath.Rel(dir, e.Path)
+			results = append(results, filepath.ToSlash(rel))
+		}
+		compareTestStringSlices(t, c.expected, results)
+	}
+}
@@ -9,10 +9,26 @@
 package ffind
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
 	"sort"
+	"strings"
 )
 
+// typeAliases - Type names kept from the original type list that are named differently in defaultTypes.
+var typeAliases = map[string]string{
+	"golang": "go",
+	"python": "py",
+	"yocto":  "bitbake",
+}
+
+// userTypes - Types added with AddType, their globs are added to the default ones.
+var userTypes = map[string][]string{}
+
+// PrintTypeList - Prints the known types and their globs, including the user defined types.
 func PrintTypeList() {
 	typeMap := getTypeMap()
 	var types []string
@@ -28,139 +44,133 @@ func PrintTypeList() {
 // KnownFileType - Given a filetype, returns true if it is known and there are
 // rules for it or false if unknown.
 func KnownFileType(fileType string) bool {
-	typeMap := getTypeMap()
-	if _, ok := typeMap[fileType]; ok {
-		return true
+	return len(typeGlobs(fileType)) > 0
+}
+
+// AddType - Adds globs to the given type, the type is created if it doesn't exist.
+// Globs are matched case insensitively against the file name using path.Match syntax.
+//
+// Types are global, add them before matching files.
+func AddType(name string, globs ...string) error {
+	if name == "" || strings.ContainsAny(name, " \t:,") {
+		return fmt.Errorf("invalid type name '%s'", name)
 	}
-	return false
+	for _, glob := range globs {
+		if glob == "" || strings.Contains(glob, "/") {
+			return fmt.Errorf("invalid glob '%s' for type '%s'", glob, name)
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid glob '%s' for type '%s': %w", glob, name, err)
+		}
+	}
+	name = typeName(name)
+	userTypes[name] = append(userTypes[name], globs...)
+	return nil
 }
 
-func getTypeMap() map[string][]string {
-	typeMap := make(map[string][]string)
-	for key, value := range typeListExt {
-		for _, ext := range value {
-			typeMap[key] = append(typeMap[key], "*"+ext)
+// AddTypeDefinition - Adds a type from a definition in the form <name>:<glob>[,<glob>...].
+// For example: tf:*.tf,*.tfvars
+func AddTypeDefinition(definition string) error {
+	i := strings.Index(definition, ":")
+	if i < 0 || strings.TrimSpace(definition[i+1:]) == "" {
+		return fmt.Errorf("invalid type definition '%s', expected <name>:<glob>[,<glob>...]", definition)
+	}
+	globs := []string{}
+	for _, glob := range strings.Split(definition[i+1:], ",") {
+		globs = append(globs, strings.TrimSpace(glob))
+	}
+	return AddType(strings.TrimSpace(definition[:i]), globs...)
+}
+
+// LoadTypesFile - Adds the types defined in file, one <name>:<glob>[,<glob>...] definition per line.
+// Empty lines and lines starting with # are ignored.
+// A missing file is not an error.
+func LoadTypesFile(file string) error {
+	fh, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
+		return fmt.Errorf("failed to open types file: %w", err)
 	}
-	for key, value := range typeListFiles {
-		typeMap[key] = append(typeMap[key], value...)
+	defer fh.Close()
+	scanner := bufio.NewScanner(fh)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		err := AddTypeDefinition(line)
+		if err != nil {
+			return fmt.Errorf("failed to load '%s' line %d: %w", file, n, err)
+		}
 	}
-	return typeMap
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read types file: %w", err)
+	}
+	Logger.Printf("Loaded types from %s", file)
+	return nil
+}
+
+// UserTypesFile - Returns the user types file, $XDG_CONFIG_HOME/ffind/types or ~/.config/ffind/types.
+func UserTypesFile() string {
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		config = filepath.Join(home, ".config")
+	}
+	return filepath.Join(config, "ffind", "types")
 }
 
-// This list was retrieved and adapted to golang from
-// https://github.com/BurntSushi/ripgrep/blob/0668c74ed49320c4224c4ea526aca07692bf590a/ignore/src/types.rs
-// Licensed under the UNLICENSE and the MIT.
+// typeName - Resolves type aliases.
+func typeName(fileType string) string {
+	if alias, ok := typeAliases[fileType]; ok {
+		return alias
+	}
+	return fileType
+}
 
-var typeListExt = map[string][]string{
-	"agda":         []string{".agda", ".lagda"},
-	"asciidoc":     []string{".adoc", ".asc", ".asciidoc"},
-	"asm":          []string{".asm", ".s", ".S"},
-	"awk":          []string{".awk"},
-	"c":            []string{".c", ".h", ".H"},
-	"cabal":        []string{".cabal"},
-	"cbor":         []string{".cbor"},
-	"ceylon":       []string{".ceylon"},
-	"clojure":      []string{".clj", ".cljc", ".cljs", ".cljx"},
-	"cmake":        []string{".cmake"},
-	"coffeescript": []string{".coffee"},
-	"config":       []string{".config"},
-	"cpp":          []string{".C", ".cc", ".cpp", ".cxx", ".h", ".H", ".hh", ".hpp", ".inl"},
-	"creole":       []string{".creole"},
-	"crystal":      []string{".cr"},
-	"cs":           []string{".cs"},
-	"csharp":       []string{".cs"},
-	"cshtml":       []string{".cshtml"},
-	"css":          []string{".css", ".scss"},
-	"cython":       []string{".pyx"},
-	"d":            []string{".d"},
-	"dart":         []string{".dart"},
-	"elisp":        []string{".el"},
-	"elixir":       []string{".ex", ".eex", ".exs"},
-	"erlang":       []string{".erl", ".hrl"},
-	"fish":         []string{".fish"},
-	"fortran":      []string{".f", ".F", ".f77", ".F77", ".pfo", ".f90", ".F90", ".f95", ".F95"},
-	"fsharp":       []string{".fs", ".fsx", ".fsi"},
-	"go":           []string{".go"},
-	"golang":       []string{".go"},
-	"groovy":       []string{".groovy", ".gradle"},
-	"h":            []string{".h", ".hpp"},
-	"haskell":      []string{".hs", ".lhs"},
-	"hbs":          []string{".hbs"},
-	"html":         []string{".htm", ".html", ".ejs"},
-	"java":         []string{".java"},
-	"jinja":        []string{".jinja", ".jinja2"},
-	"js":           []string{".js", ".jsx", ".vue"},
-	"json":         []string{".json"},
-	"jsonl":        []string{".jsonl"},
-	"julia":        []string{".jl"},
-	"kotlin":       []string{".kt", ".kts"},
-	"less":         []string{".less"},
-	"lisp":         []string{".el", ".jl", ".lisp", ".lsp", ".sc", ".scm"},
-	"log":          []string{".log"},
-	"lua":          []string{".lua"},
-	"m4":           []string{".ac", ".m4"},
-	"make":         []string{".mk", ".mak"},
-	"markdown":     []string{".markdown", ".md", ".mdown", ".mkdn"},
-	"matlab":       []string{".m"},
-	"md":           []string{".markdown", ".md", ".mdown", ".mkdn"},
-	"ml":           []string{".ml"},
-	"msbuild":      []string{".csproj", ".fsproj", ".vcxproj", ".proj", ".props", ".targets"},
-	"nim":          []string{".nim"},
-	"nix":          []string{".nix"},
-	"objc":         []string{".h", ".m"},
-	"objcpp":       []string{".h", ".mm"},
-	"ocaml":        []string{".ml", ".mli", ".mll", ".mly"},
-	"org":          []string{".org"},
-	"pdf":          []string{".pdf"},
-	"perl":         []string{".perl", ".pl", ".PL", ".plh", ".plx", ".pm", ".t"},
-	"php":          []string{".php", ".php3", ".php4", ".php5", ".phtml"},
-	"pod":          []string{".pod"},
-	"ps":           []string{".cdxml", ".ps1", ".ps1xml", ".psd1", ".psm1"},
-	"py":           []string{".py"},
-	"python":       []string{".py"},
-	"qmake":        []string{".pro", ".pri"},
-	"r":            []string{".R", ".r", ".Rmd", ".Rnw"},
-	"rdoc":         []string{".rdoc"},
-	"rst":          []string{".rst"},
-	"ruby":         []string{".gemspec", ".rb"},
-	"rust":         []string{".rs"},
-	"sass":         []string{".sass", ".scss"},
-	"scala":        []string{".scala"},
-	"sh":           []string{".bash", ".csh", ".ksh", ".sh", ".tcsh"},
-	"spark":        []string{".spark"},
-	"sql":          []string{".sql"},
-	"stylus":       []string{".styl"},
-	"sv":           []string{".v", ".vg", ".sv", ".svh", ".h"},
-	"svg":          []string{".svg"},
-	"swift":        []string{".swift"},
-	"swig":         []string{".def", ".i"},
-	"taskpaper":    []string{".taskpaper"},
-	"tcl":          []string{".tcl"},
-	"tex":          []string{".tex", ".ltx", ".cls", ".sty", ".bib"},
-	"textile":      []string{".textile"},
-	"toml":         []string{".toml"},
-	"ts":           []string{".ts", ".tsx"},
-	"twig":         []string{".twig"},
-	"txt":          []string{".txt"},
-	"vala":         []string{".vala"},
-	"vb":           []string{".vb"},
-	"vim":          []string{".vim"},
-	"vimscript":    []string{".vim"},
-	"wiki":         []string{".mediawiki", ".wiki"},
-	"xml":          []string{".xml"},
-	"yacc":         []string{".y"},
-	"yaml":         []string{".yaml", ".yml"},
-	"yocto":        []string{".bb", ".bbappend", ".bbclass"},
-	"zsh":          []string{".zsh"},
+// typeGlobs - Returns the default and user defined globs of the type.
+func typeGlobs(fileType string) []string {
+	name := typeName(fileType)
+	globs := []string{}
+	globs = append(globs, defaultTypes[name]...)
+	return append(globs, userTypes[name]...)
 }
 
-var typeListFiles = map[string][]string{
-	"cmake":   []string{"CMakeLists.txt"},
-	"crystal": []string{"Projectfile"},
-	"make":    []string{"gnumakefile", "Gnumakefile", "makefile", "Makefile"},
-	"mk":      []string{"mkfile"},
-	"ruby":    []string{"Gemfile", ".irbrc", "Rakefile"},
-	"toml":    []string{"Cargo.lock"},
-	"zsh":     []string{"zshenv", ".zshenv", "zprofile", ".zprofile", "zshrc", ".zshrc", "zlogin", ".zlogin", "zlogout", ".zlogout"},
+// matchTypeGlobs - Returns true if the file name matches any of the type globs.
+// Matching is case insensitive.
+func matchTypeGlobs(name, fileType string) bool {
+	name = strings.ToLower(name)
+	for _, glob := range typeGlobs(fileType) {
+		ok, err := path.Match(strings.ToLower(glob), name)
+		if err != nil {
+			Logger.Printf("Invalid glob %s for type %s: %s", glob, fileType, err)
+			continue
+		}
+		if ok {
+			Logger.Printf("Match %s with type %s glob %s", name, fileType, glob)
+			return true
+		}
+	}
+	return false
+}
+
+func getTypeMap() map[string][]string {
+	typeMap := make(map[string][]string)
+	for key := range defaultTypes {
+		typeMap[key] = typeGlobs(key)
+	}
+	for key := range typeAliases {
+		typeMap[key] = typeGlobs(key)
+	}
+	for key := range userTypes {
+		typeMap[key] = typeGlobs(key)
+	}
+	return typeMap
 }
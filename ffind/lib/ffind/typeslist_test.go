@@ -9,6 +9,10 @@
 package ffind
 
 import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -20,3 +24,103 @@ func TestKnownFileType(t *testing.T) {
 		t.Fatalf("Unknown FileType known")
 	}
 }
+
+func TestTypeGlobs(t *testing.T) {
+	cases := []struct {
+		fileType string
+		name     string
+		match    bool
+	}{
+		{"docker", "Dockerfile", true},
+		{"docker", "Dockerfile.dev", true},
+		{"bazel", "BUILD", true},
+		{"bazel", "BUILD.txt", false},
+		{"cmake", "CMakeLists.txt", true},
+		{"c", "main.h", true},
+		{"c", "main.hpp", false},
+		{"make", "GNUmakefile", true},
+		{"ruby", "RUBY.RB", true},
+		{"go", "A.GO", true},
+		{"golang", "main.go", true},
+		{"python", "main.py", true},
+		{"license", "COPYING-GPL", true},
+		{"license", "LICENSE.md", true},
+		{"license", "gpl-3.0.txt", true},
+		{"license", "COPYINGX", false},
+	}
+	for _, c := range cases {
+		if got := matchTypeGlobs(c.name, c.fileType); got != c.match {
+			t.Errorf("type %s, name %s: expected %v, got %v", c.fileType, c.name, c.match, got)
+		}
+	}
+}
+
+func TestDefaultTypeGlobs(t *testing.T) {
+	for name, globs := range defaultTypes {
+		for _, glob := range globs {
+			if _, err := path.Match(glob, ""); err != nil {
+				t.Errorf("type %s, invalid glob %s: %s", name, glob, err)
+			}
+		}
+	}
+}
+
+func TestAddType(t *testing.T) {
+	defer func() { userTypes = map[string][]string{} }()
+
+	if KnownFileType("hcl2") {
+		t.Fatalf("unexpected known type")
+	}
+	err := AddTypeDefinition("hcl2:*.hcl, *.tfvars")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !KnownFileType("hcl2") || !matchTypeGlobs("vars.tfvars", "hcl2") || matchTypeGlobs("state.tfstate", "hcl2") {
+		t.Errorf("unexpected hcl2 type: %v", getTypeMap()["hcl2"])
+	}
+	// Aliases and default types are extended
+	err = AddType("python", "*.pyi")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !matchTypeGlobs("lib.pyi", "py") || !matchTypeGlobs("lib.py", "python") {
+		t.Errorf("unexpected py type: %v", getTypeMap()["py"])
+	}
+
+	for _, def := range []string{"tf", "tf:", ":*.tf", "t f:*.tf", "tf:*.[tf", "tf:dir/*.tf"} {
+		if err := AddTypeDefinition(def); err == nil {
+			t.Errorf("%s: expected error", def)
+		}
+	}
+}
+
+func TestLoadTypesFile(t *testing.T) {
+	defer func() { userTypes = map[string][]string{} }()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "types")
+	err := os.WriteFile(file, []byte("# Terraform\ntf:*.tf,*.tfvars\n\njenkins:Jenkinsfile\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = LoadTypesFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !matchTypeGlobs("main.tf", "tf") || !matchTypeGlobs("Jenkinsfile", "jenkins") {
+		t.Errorf("unexpected types: %v", userTypes)
+	}
+
+	err = LoadTypesFile(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	err = os.WriteFile(file, []byte("tf:*.tf\nbad\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = LoadTypesFile(file)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected line 2 error, got: %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Walker - Concurrent alternative to ListRecursive.
+//
+// Dirs are listed by a pool of workers, each worker takes dirs from its own queue
+// and steals from the other queues when its own is empty.
+type Walker struct {
+	// Follow symlinks.
+	Follow bool
+	// FileMatcher used to skip files and dirs, defaults to a BasicFileMatch that skips nothing.
+	Matcher FileMatcher
+	// Sorts the entries of each dir, defaults to SortFnByName.
+	// Only affects the result order when Ordered is set.
+	SortFn SortFn
+	// Number of concurrent workers, defaults to runtime.NumCPU().
+	Workers int
+	// Return the results in the same order as ListRecursive.
+	// Results are buffered until all the dirs before them are listed.
+	// Otherwise results are returned as soon as their dir is listed.
+	Ordered bool
+	// Results must match all the predicates, dirs that don't match are still recursed into.
+	// When following symlinks, predicates get the info of the link target.
+	Predicates []Predicate
+	// Skip results less than MinDepth levels below the path, the entries of the path are at depth 1.
+	MinDepth int
+	// Don't recurse into dirs at MaxDepth levels below the path, 0 for no limit.
+	MaxDepth int
+	// Don't recurse into dirs in a different file system than the path, like find's -xdev.
+	// The mount point dirs are still listed.
+	OneFileSystem bool
+}
+
+// walkTask - A dir to list.
+type walkTask struct {
+	fe      *FileError
+	matcher FileMatcher
+	id      *dirID
+	// Depth of the entries of the dir
+	depth int
+
+	// Ordered mode, entries and children are set before done is closed.
+	entries  []dirEntry
+	children []*walkTask
+	done     chan struct{}
+}
+
+// root - Returns the id of the dir the walk started from.
+func (t *walkTask) root() *dirID {
+	id := t.id
+	for id != nil && id.parent != nil {
+		id = id.parent
+	}
+	return id
+}
+
+// walkQueue - Double ended queue of tasks.
+// The owner takes from the back to go deep first, thieves take from the front.
+type walkQueue struct {
+	mu    sync.Mutex
+	tasks []*walkTask
+}
+
+func (q *walkQueue) push(t *walkTask) {
+	q.mu.Lock()
+	q.tasks = append(q.tasks, t)
+	q.mu.Unlock()
+}
+
+func (q *walkQueue) popBack() *walkTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	t := q.tasks[len(q.tasks)-1]
+	q.tasks[len(q.tasks)-1] = nil
+	q.tasks = q.tasks[:len(q.tasks)-1]
+	return t
+}
+
+func (q *walkQueue) popFront() *walkTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	t := q.tasks[0]
+	q.tasks[0] = nil
+	q.tasks = q.tasks[1:]
+	return t
+}
+
+// walkScheduler - Tracks the queued and in progress tasks so idle workers know when to wait and when to exit.
+type walkScheduler struct {
+	queues []*walkQueue
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// Tasks in the queues
+	queued int
+	// Tasks in the queues or in progress
+	pending int
+}
+
+func newWalkScheduler(workers int) *walkScheduler {
+	s := &walkScheduler{}
+	s.cond = sync.NewCond(&s.mu)
+	for i := 0; i < workers; i++ {
+		s.queues = append(s.queues, &walkQueue{})
+	}
+	return s
+}
+
+// push - Adds a task to the queue of worker id.
+func (s *walkScheduler) push(id int, t *walkTask) {
+	s.queues[id].push(t)
+	s.mu.Lock()
+	s.queued++
+	s.pending++
+	s.mu.Unlock()
+	s.cond.Signal()
+}
+
+// next - Returns the next task for worker id, nil when there is no work left.
+func (s *walkScheduler) next(id int) *walkTask {
+	n := len(s.queues)
+	for {
+		t := s.queues[id].popBack()
+		for i := 1; t == nil && i < n; i++ {
+			t = s.queues[(id+i)%n].popFront()
+		}
+		if t != nil {
+			s.mu.Lock()
+			s.queued--
+			s.mu.Unlock()
+			return t
+		}
+		s.mu.Lock()
+		for s.queued == 0 && s.pending > 0 {
+			s.cond.Wait()
+		}
+		finished := s.pending == 0
+		s.mu.Unlock()
+		if finished {
+			return nil
+		}
+	}
+}
+
+// done - Marks a task returned by next as completed.
+func (s *walkScheduler) done() {
+	s.mu.Lock()
+	s.pending--
+	finished := s.pending == 0
+	s.mu.Unlock()
+	if finished {
+		s.cond.Broadcast()
+	}
+}
+
+// Walk - Returns a recursive list of FileError results under `path`.
+func (w *Walker) Walk(path string) <-chan FileError {
+	matcher := w.Matcher
+	if matcher == nil {
+		matcher = &BasicFileMatch{}
+	}
+	sortFn := w.SortFn
+	if sortFn == nil {
+		sortFn = SortFnByName
+	}
+	workers := w.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	c := make(chan FileError)
+	fe, _ := NewFileError(path)
+	root := &walkTask{fe: fe, matcher: matcher, id: rootDirID(fe, w.Follow), depth: 1, done: make(chan struct{})}
+	s := newWalkScheduler(workers)
+	s.push(0, root)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for t := s.next(id); t != nil; t = s.next(id) {
+				w.process(s, id, t, sortFn, c)
+				s.done()
+			}
+		}(i)
+	}
+
+	go func() {
+		if w.Ordered {
+			emitOrdered(root, c)
+		}
+		wg.Wait()
+		close(c)
+	}()
+	return c
+}
+
+// process - Lists the dir of the task and queues its subdirs.
+// In unordered mode the results are sent right away, in ordered mode they are saved in the task for emitOrdered.
+func (w *Walker) process(s *walkScheduler, id int, t *walkTask, sortFn SortFn, c chan<- FileError) {
+	entries := w.filter(listDir(t.fe, w.Follow, t.matcher, sortFn, t.id), t.depth, t.root())
+	if w.Ordered {
+		t.entries = entries
+		t.children = make([]*walkTask, len(entries))
+	}
+	// Queue in reverse so the owner, taking from the back, lists the first subdir next
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !e.recurse {
+			continue
+		}
+		child := &walkTask{fe: &e.FileError, matcher: e.matcher, id: e.id, depth: t.depth + 1}
+		if w.Ordered {
+			child.done = make(chan struct{})
+			t.children[i] = child
+		}
+		s.push(id, child)
+	}
+	if w.Ordered {
+		close(t.done)
+		return
+	}
+	for _, e := range entries {
+		if e.emit {
+			c <- e.FileError
+		}
+	}
+}
+
+// filter - Applies the depth limits, the file system boundary and the predicates to the entries listed at depth.
+// Entries with errors are always emitted so they can be reported, they are not filtered by MinDepth or the predicates.
+func (w *Walker) filter(entries []dirEntry, depth int, root *dirID) []dirEntry {
+	for i := range entries {
+		e := &entries[i]
+		if w.MaxDepth > 0 && depth >= w.MaxDepth {
+			e.recurse = false
+		}
+		if w.OneFileSystem && e.recurse && root != nil && e.id != nil && e.id.dev != root.dev {
+			Logger.Printf("Different file system: %s", e.Path)
+			e.recurse = false
+		}
+		if !e.emit || e.Error != nil {
+			continue
+		}
+		if depth < w.MinDepth {
+			e.emit = false
+			continue
+		}
+		if len(w.Predicates) == 0 {
+			continue
+		}
+		fe := e.FileError
+		if w.Follow && fe.IsSymlink() {
+			if fInfo, err := os.Stat(fe.Path); err == nil {
+				fe.FileInfo = fInfo
+			}
+		}
+		for _, p := range w.Predicates {
+			if !p(&fe) {
+				e.emit = false
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// emitOrdered - Sends the results of the task and its children depth first, waiting for each task to be listed.
+func emitOrdered(t *walkTask, c chan<- FileError) {
+	<-t.done
+	for i, e := range t.entries {
+		if e.emit {
+			c <- e.FileError
+		}
+		if child := t.children[i]; child != nil {
+			emitOrdered(child, c)
+			// Release the results that were already sent
+			t.children[i] = nil
+		}
+	}
+	t.entries = nil
+}
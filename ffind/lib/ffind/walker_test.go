@@ -0,0 +1,230 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ffind
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWalker(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	goToRootDir()
+	for _, workers := range []int{1, 4} {
+		for _, ordered := range []bool{true, false} {
+			for _, c := range listRecursiveCases {
+				w := &Walker{
+					Follow: true,
+					Matcher: &BasicFileMatch{
+						IgnoreDirResults:  c.ignoreDirResults,
+						IgnoreFileResults: c.ignoreFileResults,
+						IgnoreVCSDirs:     c.ignoreCVSDirs,
+					},
+					Workers: workers,
+					Ordered: ordered,
+				}
+				tree := []string{}
+				for e := range w.Walk(c.file) {
+					if e.Error != nil {
+						if !strings.Contains(e.Error.Error(), "too many links") {
+							t.Fatalf("Unexpected error: %s\n", e.Error)
+						}
+					}
+					tree = append(tree, e.Path)
+				}
+				expected := c.expected
+				if !ordered {
+					expected = append([]string{}, c.expected...)
+					sort.Strings(expected)
+					sort.Strings(tree)
+				}
+				compareTestStringSlices(t, expected, tree)
+			}
+		}
+	}
+
+	fe := (&Walker{}).Walk("non-existent")
+	e := <-fe
+	if !os.IsNotExist(e.Error) {
+		t.Errorf("Expected IsNotExist error, received: %v\n", e.Error)
+	}
+	if _, ok := <-fe; ok {
+		t.Errorf("Expected a single result")
+	}
+}
+
+// benchmarkTree - Creates a tree with dirs^depth leaf dirs, each with files files.
+func benchmarkTree(b *testing.B, dirs, depth, files int) string {
+	b.Helper()
+	root := b.TempDir()
+	var create func(dir string, level int)
+	create = func(dir string, level int) {
+		for i := 0; i < files; i++ {
+			err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%d.txt", i)), nil, 0644)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		if level == depth {
+			return
+		}
+		for i := 0; i < dirs; i++ {
+			sub := filepath.Join(dir, fmt.Sprintf("dir-%d", i))
+			err := os.Mkdir(sub, 0755)
+			if err != nil {
+				b.Fatal(err)
+			}
+			create(sub, level+1)
+		}
+	}
+	create(root, 0)
+	return root
+}
+
+func BenchmarkListRecursive(b *testing.B) {
+	root := benchmarkTree(b, 8, 3, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for range ListRecursive(root, true, &BasicFileMatch{}, SortFnByName) {
+		}
+	}
+}
+
+func BenchmarkWalker(b *testing.B) {
+	root := benchmarkTree(b, 8, 3, 10)
+	for _, workers := range []int{1, 4, 16} {
+		for _, ordered := range []bool{false, true} {
+			b.Run(fmt.Sprintf("workers=%d/ordered=%v", workers, ordered), func(b *testing.B) {
+				w := &Walker{Follow: true, Workers: workers, Ordered: ordered}
+				for i := 0; i < b.N; i++ {
+					for range w.Walk(root) {
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWalkerLoop(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a/b/file": "",
+		"c/file":   "",
+	})
+	links := map[string]string{
+		// Loops
+		"a/b/up":   "..",
+		"a/b/root": "../..",
+		"a/self":   ".",
+		// Not loops, the same dir listed twice
+		"a/c":  "../c",
+		"a/c2": "../c",
+	}
+	for name, target := range links {
+		err := os.Symlink(target, filepath.Join(dir, name))
+		if err != nil {
+			t.Skipf("symlinks not supported: %s", err)
+		}
+	}
+
+	expected := []string{"a", "a/b", "a/b/file", "a/b/root", "a/b/up", "a/c", "a/c/file", "a/c2", "a/c2/file", "a/self", "c", "c/file"}
+	expectedLoops := []string{"a/b/root", "a/b/up", "a/self"}
+	check := func(ch <-chan FileError) {
+		t.Helper()
+		results, loops := []string{}, []string{}
+		for e := range ch {
+			rel, _ := filepath.Rel(dir, e.Path)
+			rel = filepath.ToSlash(rel)
+			if e.Error != nil {
+				if !errors.Is(e.Error, ErrLoop) {
+					t.Fatalf("unexpected error: %s", e.Error)
+				}
+				loops = append(loops, rel)
+			}
+			results = append(results, rel)
+		}
+		sort.Strings(results)
+		sort.Strings(loops)
+		compareTestStringSlices(t, expected, results)
+		compareTestStringSlices(t, expectedLoops, loops)
+	}
+	check(ListRecursive(dir, true, &BasicFileMatch{}, SortFnByName))
+	for _, workers := range []int{1, 4} {
+		for _, ordered := range []bool{true, false} {
+			check((&Walker{Follow: true, Workers: workers, Ordered: ordered}).Walk(dir))
+		}
+	}
+	// Starting from a symlink to a, the loops are a/self, a/b/up and a under a/b/root
+	link := filepath.Join(t.TempDir(), "link")
+	err := os.Symlink(filepath.Join(dir, "a"), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loops := 0
+	for e := range (&Walker{Follow: true}).Walk(link) {
+		if errors.Is(e.Error, ErrLoop) {
+			loops++
+		}
+	}
+	if loops != 3 {
+		t.Errorf("expected 3 loops, got %d", loops)
+	}
+}
+
+func TestWalkerOneFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a/file": "",
+	})
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirDev, _, ok := fileID(dirInfo)
+	if !ok {
+		t.Skip("file system ids not supported")
+	}
+	// Link to a dir in a different file system to cross a boundary without mounting one
+	mount := ""
+	for _, m := range []string{"/dev", "/proc/self", "/sys/kernel", "/run"} {
+		fInfo, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if dev, _, _ := fileID(fInfo); dev != dirDev {
+			mount = m
+			break
+		}
+	}
+	if mount == "" {
+		t.Skip("no dir in a different file system found")
+	}
+	err = os.Symlink(mount, filepath.Join(dir, "mount"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Walker{Follow: true, OneFileSystem: true, Ordered: true}
+	results := []string{}
+	for e := range w.Walk(dir) {
+		if e.Error != nil {
+			t.Fatalf("unexpected error: %s", e.Error)
+		}
+		rel, _ := filepath.Rel(dir, e.Path)
+		results = append(results, filepath.ToSlash(rel))
+	}
+	compareTestStringSlices(t, []string{"a", "a/file", "mount"}, results)
+}
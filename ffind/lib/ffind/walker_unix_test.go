@@ -0,0 +1,57 @@
+// This file is part of ffind.
+//
+// Copyright (C) 2017-2022  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+//go:build !windows
+// +build !windows
+
+package ffind
+
+import (
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWalkerFIFO(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"script": "#!/bin/sh\necho hello\n",
+	})
+	fifo := filepath.Join(dir, "fifo")
+	err := syscall.Mkfifo(fifo, 0644)
+	if err != nil {
+		t.Skipf("fifos not supported: %s", err)
+	}
+
+	// Opening a fifo without a writer blocks
+	done := make(chan []string)
+	go func() {
+		fm := &BasicFileMatch{DetectContent: true, MatchFileTypeList: []string{"sh"}}
+		results := []string{}
+		for e := range ListRecursive(dir, true, fm, SortFnByName) {
+			if e.Error != nil {
+				t.Errorf("unexpected error: %s", e.Error)
+			}
+			rel, _ := filepath.Rel(dir, e.Path)
+			results = append(results, filepath.ToSlash(rel))
+		}
+		if IsBinaryFile(fifo) {
+			t.Errorf("fifo detected as binary")
+		}
+		sort.Strings(results)
+		done <- results
+	}()
+	select {
+	case results := <-done:
+		compareTestStringSlices(t, []string{"script"}, results)
+	case <-time.After(5 * time.Second):
+		t.Fatal("listing blocked reading the fifo")
+	}
+}
@@ -36,8 +36,6 @@ TODO: Look into adding option to ignore reporting broken symlinks.
 
 TODO: Implement version sort.
 
-TODO: Limit depth
-
 */
 
 package main
@@ -50,6 +48,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/DavidGamba/dgtools/ffind/lib/ffind"
 	"github.com/DavidGamba/dgtools/ffind/semver"
@@ -93,8 +92,14 @@ func main() {
 }
 
 func program(args []string) int {
-	var vcs, hidden, caseSensitive, follow, abspath bool
-	var sortNum, typeDir, typeFile bool
+	var vcs, hidden, caseSensitive, follow, abspath, noIgnore bool
+	var sortNum, typeDir, typeFile, unordered bool
+	var workers, minDepth, maxDepth int
+	var empty, print0, jsonOutput bool
+	var text, binary, noDetect, oneFileSystem bool
+	var fuzzy bool
+	var top int
+	var newer, perm, owner string
 	var fileType []string
 	opt := getoptions.New()
 	opt.SetMode(getoptions.SingleDash)
@@ -105,15 +110,43 @@ func program(args []string) int {
 	opt.Bool("type-list", false, opt.Alias("typelist"))
 	opt.BoolVar(&vcs, "vcs", true, opt.Description("Sets --hidden when set."))
 	opt.BoolVar(&hidden, "hidden", true)
+	opt.BoolVar(&noIgnore, "no-ignore", false, opt.Description("Do not skip files ignored by .gitignore and .ignore files."))
 	opt.BoolVar(&caseSensitive, "case", false)
 	opt.BoolVar(&follow, "no-follow", true)
 	opt.BoolVar(&abspath, "abs-path", false)
 	opt.BoolVar(&sortNum, "num-sort", false)
+	opt.IntVar(&workers, "workers", 0, opt.Description("Number of dirs listed concurrently, defaults to the number of CPUs."))
+	opt.BoolVar(&unordered, "unordered", false, opt.Description("Print results as soon as they are found instead of in sorted order."))
 	fileTypeWithFileAndDir := opt.StringSlice("t", 1, 1, opt.Alias("type"))
 	noFileType := opt.StringSlice("T", 1, 1, opt.Alias("no-type"))
+	typeAddList := opt.StringSlice("type-add", 1, 1, opt.ArgName("name:glob,glob"),
+		opt.Description("Add globs to a type, like 'tf:*.tf,*.tfvars'. Can be repeated.\nTypes are also loaded from ~/.config/ffind/types, one definition per line."))
 	matchExtensionList := opt.StringSlice("e", 1, 1, opt.Alias("extension"))
 	ignoreExtensionList := opt.StringSlice("E", 1, 1, opt.Alias("no-extension"))
-	remaining, err := opt.Parse(args[1:])
+	sizeList := opt.StringSlice("size", 1, 1, opt.Description("Match file size, [+-]<n>[c|k|M|G|T], like +10M. Can be repeated."))
+	mtimeList := opt.StringSlice("mtime", 1, 1, opt.Description("Match time since last modification, [+-]<n>[s|m|h|d|w], like -2d. Can be repeated."))
+	opt.StringVar(&newer, "newer", "", opt.ArgName("file"), opt.Description("Match files modified more recently than <file>."))
+	opt.StringVar(&perm, "perm", "", opt.Description("Match octal permissions: exact <mode>, all bits -<mode> or any bit /<mode>."))
+	opt.StringVar(&owner, "owner", "", opt.Description("Match owner: <user>, :<group> or <user>:<group>."))
+	opt.BoolVar(&empty, "empty", false, opt.Description("Match empty files and dirs."))
+	opt.BoolVar(&text, "text", false, opt.Description("Match text files, detected from the start of their content."))
+	opt.BoolVar(&binary, "binary", false, opt.Description("Match binary files, detected from the start of their content."))
+	opt.BoolVar(&noDetect, "no-detect", false, opt.Description("Do not match files without an extension to types by their shebang."))
+	opt.IntVar(&minDepth, "min-depth", 0, opt.Description("Skip results less than <n> levels below the dir."))
+	opt.IntVar(&maxDepth, "max-depth", 0, opt.Description("Don't recurse more than <n> levels below the dir."))
+	opt.BoolVar(&oneFileSystem, "one-file-system", false, opt.Alias("xdev"), opt.Description("Don't recurse into dirs in a different file system."))
+	opt.BoolVar(&print0, "print0", false, opt.Alias("0"), opt.Description("Separate results with NUL instead of newline."))
+	opt.BoolVar(&jsonOutput, "json", false, opt.Description("Print results as JSON lines with path, size, mode and mtime."))
+	opt.BoolVar(&fuzzy, "fuzzy", false, opt.Description("Match the pattern as a subsequence of the relative path and print the best matches first."))
+	opt.IntVar(&top, "top", 20, opt.Description("Number of --fuzzy results to print, 0 for all."))
+
+	args, execCmd, batchCmd, err := extractExec(args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		return 1
+	}
+	args = joinNegativeValues(args, "size", "mtime", "perm")
+	remaining, err := opt.Parse(args)
 	if opt.Called("help") {
 		fmt.Println(opt.Help())
 		synopsis()
@@ -128,13 +161,25 @@ func program(args []string) int {
 		fmt.Println(version)
 		return 1
 	}
+	if opt.Called("debug") {
+		Logger.SetOutput(os.Stderr)
+	}
+	err = ffind.LoadTypesFile(ffind.UserTypesFile())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		return 1
+	}
+	for _, def := range *typeAddList {
+		err = ffind.AddTypeDefinition(def)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			return 1
+		}
+	}
 	if opt.Called("type-list") {
 		ffind.PrintTypeList()
 		return 0
 	}
-	if opt.Called("debug") {
-		Logger.SetOutput(os.Stderr)
-	}
 	Logger.Println(remaining)
 
 	// ctx, cancel, done := getoptions.InterruptContext()
@@ -204,9 +249,15 @@ func program(args []string) int {
 	Logger.Printf("filePattern: %s\n", filePattern)
 	Logger.Printf("Ext: %v\n", ignoreExtensionList)
 	var r *regexp.Regexp
-	if caseSensitive {
+	switch {
+	case fuzzy:
+		// The default pattern matches every path
+		if filePattern == "." {
+			filePattern = ""
+		}
+	case caseSensitive:
 		r, err = regexp.Compile(filePattern)
-	} else {
+	default:
 		r, err = regexp.Compile("(?i)" + filePattern)
 	}
 	if err != nil {
@@ -214,6 +265,58 @@ func program(args []string) int {
 		return 1
 	}
 
+	predicates := []ffind.Predicate{}
+	for _, s := range *sizeList {
+		p, err := ffind.SizePredicate(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			return 1
+		}
+		predicates = append(predicates, p)
+	}
+	now := time.Now()
+	for _, s := range *mtimeList {
+		p, err := ffind.MtimePredicate(s, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			return 1
+		}
+		predicates = append(predicates, p)
+	}
+	if newer != "" {
+		p, err := ffind.NewerPredicate(newer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			return 1
+		}
+		predicates = append(predicates, p)
+	}
+	if perm != "" {
+		p, err := ffind.PermPredicate(perm)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			return 1
+		}
+		predicates = append(predicates, p)
+	}
+	if owner != "" {
+		p, err := ffind.OwnerPredicate(owner)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			return 1
+		}
+		predicates = append(predicates, p)
+	}
+	if empty {
+		predicates = append(predicates, ffind.EmptyPredicate)
+	}
+	if text {
+		predicates = append(predicates, ffind.TextPredicate)
+	}
+	if binary {
+		predicates = append(predicates, ffind.BinaryPredicate)
+	}
+
 	var sfn ffind.SortFn
 	if sortNum {
 		sfn = ffind.SortFnByNum
@@ -221,35 +324,112 @@ func program(args []string) int {
 		sfn = ffind.SortFnByName
 	}
 
-	ch := ffind.ListRecursive(
-		dir,
-		follow,
-		&ffind.BasicFileMatch{
-			IgnoreDirResults:        typeFile,
-			IgnoreFileResults:       typeDir,
-			IgnoreVCSDirs:           vcs,
-			IgnoreHidden:            hidden,
-			IgnoreFileExtensionList: *ignoreExtensionList,
-			IgnoreFileTypeList:      *noFileType,
-			MatchFileExtensionList:  *matchExtensionList,
-			MatchFileTypeList:       fileType,
-		},
-		sfn)
+	var fm ffind.FileMatcher = &ffind.BasicFileMatch{
+		IgnoreDirResults:        typeFile,
+		IgnoreFileResults:       typeDir,
+		IgnoreVCSDirs:           vcs,
+		IgnoreHidden:            hidden,
+		IgnoreFileExtensionList: *ignoreExtensionList,
+		IgnoreFileTypeList:      *noFileType,
+		MatchFileExtensionList:  *matchExtensionList,
+		MatchFileTypeList:       fileType,
+		DetectContent:           !noDetect,
+	}
+	if !noIgnore {
+		fm = ffind.NewIgnoreFileMatch(fm)
+	}
+
+	w := &ffind.Walker{
+		Follow:        follow,
+		Matcher:       fm,
+		SortFn:        sfn,
+		Workers:       workers,
+		Ordered:       !unordered,
+		Predicates:    predicates,
+		MinDepth:      minDepth,
+		MaxDepth:      maxDepth,
+		OneFileSystem: oneFileSystem,
+	}
+	exitCode := 0
+	batch := []string{}
+	// output - Prints the result or runs the exec commands on it, returns false on a fatal error.
+	output := func(e ffind.FileError) bool {
+		path := e.Path
+		if abspath {
+			path = filepath.Join(absdir, e.Path)
+		}
+		switch {
+		case execCmd != nil || batchCmd != nil:
+			if execCmd != nil {
+				err := runCommand(execCommand(execCmd, path))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+					exitCode = 1
+				}
+			}
+			if batchCmd != nil {
+				batch = append(batch, path)
+			}
+		case jsonOutput:
+			err := printJSON(os.Stdout, path, e)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				return false
+			}
+		case print0:
+			fmt.Printf("%s\x00", path)
+		default:
+			fmt.Println(path)
+		}
+		return true
+	}
+	fuzzyResults := []ffind.FuzzyResult{}
+	ch := w.Walk(dir)
 	for e := range ch {
+		// Errors are only reported, they don't go through the filters so they are not results for the actions
 		if e.Error != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: '%s' %s\n", e.Path, e.Error)
-			if os.IsNotExist(e.Error) {
-				continue
-			}
+			continue
 		}
 		Logger.Printf("ffind: %s\n", e.Path)
-		if r.MatchString(filepath.Base(e.Path)) {
-			if abspath {
-				fmt.Println(filepath.Join(absdir, e.Path))
-			} else {
-				fmt.Println(e.Path)
+		if fuzzy {
+			rel, err := filepath.Rel(dir, e.Path)
+			if err != nil {
+				rel = e.Path
+			}
+			rel = filepath.ToSlash(rel)
+			if score, ok := ffind.FuzzyMatch(filePattern, rel, caseSensitive); ok {
+				fuzzyResults = append(fuzzyResults, ffind.FuzzyResult{FileError: e, Score: score, Match: rel})
+			}
+			continue
+		}
+		if !r.MatchString(filepath.Base(e.Path)) {
+			continue
+		}
+		if !output(e) {
+			return 1
+		}
+	}
+	if fuzzy {
+		ffind.SortFuzzyResults(fuzzyResults)
+		if top > 0 && len(fuzzyResults) > top {
+			fuzzyResults = fuzzyResults[:top]
+		}
+		for _, fr := range fuzzyResults {
+			Logger.Printf("score %d: %s\n", fr.Score, fr.Match)
+			if !output(fr.FileError) {
+				return 1
+			}
+		}
+	}
+	if batchCmd != nil && len(batch) > 0 {
+		for _, cmd := range batchCommands(batchCmd, batch) {
+			err := runCommand(cmd)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				exitCode = 1
 			}
 		}
 	}
-	return 0
+	return exitCode
 }
@@ -23,11 +23,10 @@ import (
 	"strconv"
 	"strings"
 
-	greppLib "github.com/DavidGamba/dgtools/grepp/lib/grepp"
+	"github.com/DavidGamba/dgtools/ffind/lib/ffind"
 	l "github.com/DavidGamba/dgtools/grepp/logging"
 	"github.com/DavidGamba/dgtools/grepp/runInPager"
 	"github.com/DavidGamba/dgtools/grepp/semver"
-	"github.com/DavidGamba/ffind/lib/ffind"
 	"github.com/DavidGamba/go-getoptions"
 	"github.com/mgutz/ansi"
 )
@@ -250,6 +249,7 @@ type grepp struct {
 	filenameOnly  bool
 	replace       string
 	force         bool
+	noIgnore      bool
 	context       int
 	searchBase    string
 	// Controls whether or not to show the filename. If the given location is a
@@ -261,6 +261,8 @@ type grepp struct {
 	filePattern          string
 	ignoreFilePattern    string
 	ignoreExtensionList  []string
+	typeList             []string
+	ignoreTypeList       []string
 	Stdout               io.Writer
 	Stderr               io.Writer
 }
@@ -274,17 +276,19 @@ func (g grepp) getFileList() <-chan ffind.FileError {
 	c := make(chan ffind.FileError)
 	go func() {
 		if g.showFile {
-			ch := ffind.ListRecursive(
-				g.searchBase,
-				true,
-				&ffind.BasicFileMatch{
-					IgnoreDirResults:        true,
-					IgnoreFileResults:       false,
-					IgnoreVCSDirs:           true,
-					IgnoreHidden:            true,
-					IgnoreFileExtensionList: g.ignoreExtensionList,
-				},
-				ffind.SortFnByName)
+			var fm ffind.FileMatcher = &ffind.BasicFileMatch{
+				IgnoreDirResults:        true,
+				IgnoreFileResults:       false,
+				IgnoreVCSDirs:           true,
+				IgnoreHidden:            true,
+				IgnoreFileExtensionList: g.ignoreExtensionList,
+				MatchFileTypeList:       g.typeList,
+				IgnoreFileTypeList:      g.ignoreTypeList,
+			}
+			if !g.noIgnore {
+				fm = ffind.NewIgnoreFileMatch(fm)
+			}
+			ch := ffind.ListRecursive(g.searchBase, true, fm, ffind.SortFnByName)
 			for e := range ch {
 				if e.Error != nil {
 					fmt.Fprintf(os.Stderr, "ERROR: '%s' %s\n", e.Path, e.Error)
@@ -292,6 +296,9 @@ func (g grepp) getFileList() <-chan ffind.FileError {
 					if os.IsNotExist(e.Error) {
 						continue
 					}
+				} else if fInfo, err := os.Stat(e.Path); err == nil && !fInfo.Mode().IsRegular() {
+					// Reading FIFOs, sockets or devices blocks
+					continue
 				}
 				c <- e
 			}
@@ -309,7 +316,7 @@ func (g grepp) getFileList() <-chan ffind.FileError {
 func (g grepp) Run() {
 	for ch := range g.getFileList() {
 		filename := ch.Path
-		if g.ignoreBinary == true && !greppLib.IsTextMIME(filename) {
+		if g.ignoreBinary == true && ffind.IsBinaryFile(filename) {
 			continue
 		}
 		if g.filenameOnly {
@@ -398,13 +405,16 @@ func synopsis() {
 	synopsis := `grepp <pattern> [<location>] [-r <replace pattern> [-f]]
       [-I] [-c] [-n] [-l] [--ignore-extension|--ie <ext>] [--color]
       [--buffer <size>] [--show-buffer-errors|--sbe]
-      [--no-pager]
+      [--no-pager] [--no-ignore]
+      [-t|--type <type>] [-T|--no-type <type>] [--type-add <name:glob,glob>]
       [--debug | --trace]
 
 # not available yet
 [-C <lines of context>] [--fp] [--name <file pattern>]
 [--spacing] [--ignore <file pattern>]
 
+grepp --type-list # show type list
+
 grepp --version
 
 grepp -h # show this help
@@ -445,6 +455,7 @@ func main() {
 	opt.BoolVar(&g.filenameOnly, "l", false)
 	opt.StringVar(&g.replace, "r", "")
 	opt.BoolVar(&g.force, "f", false)
+	opt.BoolVar(&g.noIgnore, "no-ignore", false)
 	opt.IntVar(&g.context, "C", 0)
 	opt.IntVar(&bufferSize, "buffer", 16384)
 	opt.BoolVar(&g.showBufferSizeErrors, "show-buffer-errors", false, opt.Alias("sbe"))
@@ -452,6 +463,10 @@ func main() {
 	opt.BoolVar(&debug, "debug", false) // debug logging
 	opt.BoolVar(&trace, "trace", false) // trace logging
 	ie := opt.StringSlice("ignore-extension", 1, 1, opt.Alias("ie"))
+	opt.StringSliceVar(&g.typeList, "type", 1, 1, opt.Alias("t"))
+	opt.StringSliceVar(&g.ignoreTypeList, "no-type", 1, 1, opt.Alias("T"))
+	typeAddList := opt.StringSlice("type-add", 1, 1)
+	opt.Bool("type-list", false)
 	// "fp"      // fullPath - Used to show the file full path instead of the relative to the current dir.
 	// "name"    // filePattern - Use to further filter the search to files matching that pattern.
 	// "ignore"  // ignoreFilePattern - Use to further filter the search to files not matching that pattern.
@@ -478,6 +493,27 @@ func main() {
 		g.ignoreExtensionList = append(g.ignoreExtensionList, ext)
 	}
 
+	// Types are shared with ffind
+	err = ffind.LoadTypesFile(ffind.UserTypesFile())
+	if err != nil {
+		l.Error.Fatal(err)
+	}
+	for _, def := range *typeAddList {
+		err = ffind.AddTypeDefinition(def)
+		if err != nil {
+			l.Error.Fatal(err)
+		}
+	}
+	if opt.Called("type-list") {
+		ffind.PrintTypeList()
+		os.Exit(0)
+	}
+	for _, t := range append(g.typeList, g.ignoreTypeList...) {
+		if !ffind.KnownFileType(t) {
+			l.Error.Fatalf("Provided type is not valid '%s'", t)
+		}
+	}
+
 	// Check if stdout is pipe p or device D
 	statStdout, _ := os.Stdout.Stat()
 	stdoutIsDevice := (statStdout.Mode() & os.ModeDevice) != 0
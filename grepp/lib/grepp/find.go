@@ -16,6 +16,7 @@ import (
 
 // TODO: Check if there are any additional mime types we should allow.
 // IsTextMIME - Determines if the file is a text based file by its extension.
+// grepp uses ffind.IsBinaryFile instead, which checks the file content.
 func IsTextMIME(filename string) bool {
 	ext := path.Ext(filename)
 	// If there is no extension assume binary
@@ -0,0 +1,3 @@
+module github.com/DavidGamba/dgtools/httputils
+
+go 1.16
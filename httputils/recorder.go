@@ -0,0 +1,385 @@
+package httputils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrInteractionNotFound - Replay mode couldn't find a recorded interaction matching the request.
+var ErrInteractionNotFound = errors.New("no recorded interaction matches request")
+
+// RecorderMode - Controls whether the Recorder talks to the network or not.
+type RecorderMode int
+
+const (
+	// ModeReplay - Only serve responses from the cassette, never hit the network.
+	ModeReplay RecorderMode = iota
+	// ModeRecord - Always hit the network and save every interaction to the cassette.
+	ModeRecord
+	// ModeReplayOrRecord - Serve from the cassette when there is a match, otherwise hit the network and record.
+	ModeReplayOrRecord
+)
+
+// Redacted - Value used to replace redacted header values.
+const Redacted = "REDACTED"
+
+// Cassette - Set of recorded request/response pairs.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction - A single recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest - Request as saved in the cassette.
+type RecordedRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// RecordedResponse - Response as saved in the cassette.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// Matcher - Determines if an incoming request matches a recorded one.
+// The body given is the incoming request body after redaction.
+type Matcher func(r *http.Request, body []byte, recorded RecordedRequest) bool
+
+// MatchMethod - Matches on the request method.
+func MatchMethod(r *http.Request, body []byte, recorded RecordedRequest) bool {
+	return r.Method == recorded.Method
+}
+
+// MatchURL - Matches on the full request URL, including the query string.
+func MatchURL(r *http.Request, body []byte, recorded RecordedRequest) bool {
+	return r.URL.String() == recorded.URL
+}
+
+// MatchBody - Matches on the request body.
+func MatchBody(r *http.Request, body []byte, recorded RecordedRequest) bool {
+	b, err := decodeBody(recorded.Body, recorded.BodyBase64)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(body, b)
+}
+
+// RecorderOptions - Internal options store
+type RecorderOptions struct {
+	transport     http.RoundTripper
+	redactHeaders []string
+	redactBody    []func([]byte) []byte
+	matchers      []Matcher
+}
+
+// RecorderOptionFn - Options type
+type RecorderOptionFn func(*RecorderOptions)
+
+// RecorderTransport - Set the transport used to talk to the network when recording.
+// Defaults to http.DefaultTransport.
+func RecorderTransport(rt http.RoundTripper) RecorderOptionFn {
+	return func(options *RecorderOptions) {
+		options.transport = rt
+	}
+}
+
+// RedactHeaders - Replace the value of the given request and response headers with Redacted before saving.
+// Header names are case insensitive.
+// The names are added to the default list: Authorization, Cookie, Set-Cookie and Proxy-Authorization.
+func RedactHeaders(names ...string) RecorderOptionFn {
+	return func(options *RecorderOptions) {
+		options.redactHeaders = append(options.redactHeaders, names...)
+	}
+}
+
+// RedactBody - Apply fn to request and response bodies before saving.
+// Incoming request bodies are also passed through fn before matching so they compare equal to the saved ones.
+func RedactBody(fn func([]byte) []byte) RecorderOptionFn {
+	return func(options *RecorderOptions) {
+		options.redactBody = append(options.redactBody, fn)
+	}
+}
+
+// RedactBodyRegexp - Replace all matches of re in request and response bodies with repl before saving.
+// repl supports the same expansion as regexp.ReplaceAll.
+func RedactBodyRegexp(re *regexp.Regexp, repl string) RecorderOptionFn {
+	return RedactBody(func(b []byte) []byte {
+		return re.ReplaceAll(b, []byte(repl))
+	})
+}
+
+// MatchOn - Set the rules used to find a recorded interaction for an incoming request.
+// All matchers must succeed.
+// Defaults to MatchMethod and MatchURL.
+func MatchOn(matchers ...Matcher) RecorderOptionFn {
+	return func(options *RecorderOptions) {
+		options.matchers = matchers
+	}
+}
+
+// Recorder - http.RoundTripper that records interactions to a cassette file and replays them.
+//
+// Replay serves interactions in recorded order: the first unused matching interaction is returned.
+// Once all matching interactions have been used, the last matching one is returned again.
+type Recorder struct {
+	mode     RecorderMode
+	path     string
+	options  RecorderOptions
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder - Returns a Recorder backed by the cassette file at path.
+// In ModeRecord the cassette starts empty and is overwritten.
+// In ModeReplay the cassette file must exist.
+func NewRecorder(path string, mode RecorderMode, fns ...RecorderOptionFn) (*Recorder, error) {
+	options := RecorderOptions{
+		transport:     http.DefaultTransport,
+		redactHeaders: []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"},
+		matchers:      []Matcher{MatchMethod, MatchURL},
+	}
+	for _, fn := range fns {
+		fn(&options)
+	}
+	r := &Recorder{
+		mode:    mode,
+		path:    path,
+		options: options,
+	}
+	if mode == ModeRecord {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if mode == ModeReplayOrRecord && os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	err = json.Unmarshal(data, &r.cassette)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cassette '%s': %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client - Returns an http.Client that uses the Recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip - Implements the http.RoundTripper interface.
+// The request given is not modified, a clone is sent to the network when recording.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	redactedBody := r.redactBody(reqBody)
+
+	if r.mode != ModeRecord {
+		// Copy the response while holding the lock, recording can grow the interactions concurrently
+		r.mu.Lock()
+		i, ok := r.find(req, redactedBody)
+		var recorded RecordedResponse
+		if ok {
+			recorded = r.cassette.Interactions[i].Response
+		}
+		r.mu.Unlock()
+		if ok {
+			Logger.Printf("replay: %s %s\n", req.Method, req.URL)
+			return recorded.toResponse(req)
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+		}
+	}
+
+	Logger.Printf("record: %s %s\n", req.Method, req.URL)
+	out := req.Clone(req.Context())
+	if reqBody != nil {
+		out.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	resp, err := r.options.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.redactHeader(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+		},
+	}
+	interaction.Request.Body, interaction.Request.BodyBase64 = encodeBody(redactedBody)
+	interaction.Response.Body, interaction.Response.BodyBase64 = encodeBody(r.redactBody(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.used = append(r.used, true)
+	err = r.save()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Save - Writes the cassette to disk.
+// Interactions are already saved as they are recorded, this is only needed if the cassette was modified.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save()
+}
+
+// Cassette - Returns the interactions currently loaded or recorded.
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	_ = os.MkdirAll(filepath.Dir(r.path), os.ModePerm)
+	err = os.WriteFile(r.path, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// find - Must be called with the lock held.
+func (r *Recorder) find(req *http.Request, body []byte) (int, bool) {
+	last := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !r.match(req, body, interaction.Request) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return i, true
+		}
+		last = i
+	}
+	return last, last >= 0
+}
+
+func (r *Recorder) match(req *http.Request, body []byte, recorded RecordedRequest) bool {
+	for _, m := range r.options.matchers {
+		if !m(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for k := range out {
+		for _, name := range r.options.redactHeaders {
+			if strings.EqualFold(k, name) {
+				out[k] = []string{Redacted}
+			}
+		}
+	}
+	return out
+}
+
+func (r *Recorder) redactBody(b []byte) []byte {
+	for _, fn := range r.options.redactBody {
+		b = fn(b)
+	}
+	return b
+}
+
+func (rr RecordedResponse) toResponse(req *http.Request) (*http.Response, error) {
+	body, err := decodeBody(rr.Body, rr.BodyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode recorded body: %w", err)
+	}
+	header := rr.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.StatusCode, http.StatusText(rr.StatusCode)),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// readRequestBody - Reads and closes the request body.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return b, nil
+}
+
+// encodeBody - Keeps text bodies readable in the cassette and base64 encodes binary ones.
+func encodeBody(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return "", base64.StdEncoding.EncodeToString(b)
+}
+
+func decodeBody(s, b64 string) ([]byte, error) {
+	if b64 != "" {
+		return base64.StdEncoding.DecodeString(b64)
+	}
+	return []byte(s), nil
+}
@@ -0,0 +1,217 @@
+package httputils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, "%s %s %d %s", r.Method, r.URL.Path, calls, body)
+	}))
+	defer ts.Close()
+
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+	do := func(t *testing.T, c *http.Client, method, path, body string) (string, error) {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := c.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return string(b), nil
+	}
+
+	t.Run("record", func(t *testing.T) {
+		r, err := NewRecorder(cassette, ModeRecord,
+			RedactBodyRegexp(regexp.MustCompile(`password=\w+`), "password=xxx"),
+			MatchOn(MatchMethod, MatchURL, MatchBody))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		c := r.Client()
+		for _, body := range []string{"password=hunter2", "a", "a"} {
+			_, err = do(t, c, "POST", "/x", body)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+		data, err := os.ReadFile(cassette)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		for _, secret := range []string{"hunter2", "Bearer token", "session=secret"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("cassette contains secret %q:\n%s", secret, data)
+			}
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		r, err := NewRecorder(cassette, ModeReplay,
+			RedactBodyRegexp(regexp.MustCompile(`password=\w+`), "password=xxx"),
+			MatchOn(MatchMethod, MatchURL, MatchBody))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		c := r.Client()
+		tests := []struct {
+			body     string
+			expected string
+		}{
+			{"a", "POST /x 2 a"},
+			{"a", "POST /x 3 a"},
+			{"a", "POST /x 3 a"},
+			{"password=other", "POST /x 1 password=xxx"},
+		}
+		for _, test := range tests {
+			got, err := do(t, c, "POST", "/x", test.body)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		}
+		_, err = do(t, c, "GET", "/x", "a")
+		if !errors.Is(err, ErrInteractionNotFound) {
+			t.Errorf("expected ErrInteractionNotFound, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("replay hit the network, calls: %d", calls)
+		}
+	})
+
+	t.Run("replay or record", func(t *testing.T) {
+		r, err := NewRecorder(cassette, ModeReplayOrRecord)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		c := r.Client()
+		_, err = do(t, c, "GET", "/y", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if calls != 4 {
+			t.Errorf("expected a network call, calls: %d", calls)
+		}
+		if len(r.Cassette().Interactions) != 4 {
+			t.Errorf("expected 4 interactions, got %d", len(r.Cassette().Interactions))
+		}
+	})
+
+	t.Run("missing cassette", func(t *testing.T) {
+		_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+}
+
+func TestRecorderRequestAndHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s", body)
+	}))
+	defer ts.Close()
+
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+	r, err := NewRecorder(cassette, ModeRecord, RedactHeaders("X-Api-Key"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req, err := http.NewRequest("POST", ts.URL+"/x", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Api-Key", "key")
+	body := req.Body
+	resp, err := r.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "hello" {
+		t.Errorf("expected %q, got %q", "hello", b)
+	}
+	if req.Body != body {
+		t.Errorf("caller request body was replaced")
+	}
+
+	h := r.Cassette().Interactions[0].Request.Header
+	for _, name := range []string{"Authorization", "X-Api-Key"} {
+		if h.Get(name) != Redacted {
+			t.Errorf("expected %s to be redacted, got %q", name, h.Get(name))
+		}
+	}
+}
+
+func TestRecorderConcurrent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s", r.URL.Path)
+	}))
+	defer ts.Close()
+
+	r, err := NewRecorder(filepath.Join(t.TempDir(), "cassette.json"), ModeReplayOrRecord)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	c := r.Client()
+	resp, err := c.Get(ts.URL + "/0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	// Replay /0 while recording new interactions
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := "/0"
+			if i%2 == 1 {
+				path = fmt.Sprintf("/%d", i)
+			}
+			resp, err := c.Get(ts.URL + path)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(b) != path {
+				t.Errorf("expected %q, got %q", path, b)
+			}
+		}(i)
+	}
+	wg.Wait()
+}